@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/services"
+)
+
+type contextKey int
+
+const (
+	userContextKey contextKey = iota
+	sessionContextKey
+)
+
+// AuthMiddleware - membungkus handler agar hanya bisa diakses dengan access token yang valid
+type AuthMiddleware struct {
+	service *services.AuthService
+}
+
+func NewAuthMiddleware(service *services.AuthService) *AuthMiddleware {
+	return &AuthMiddleware{service: service}
+}
+
+// Permissions - permission yang dibutuhkan sebuah route per HTTP method, key "*" berlaku untuk method lain.
+// Key "METHOD /aksi" (misalnya "POST /approve") berlaku untuk path yang berakhiran /aksi dengan method tersebut.
+type Permissions map[string]string
+
+// Any - satu permission untuk semua method
+func Any(permission string) Permissions {
+	return Permissions{"*": permission}
+}
+
+// forRequest - permission untuk aksi atau method request, kosong berarti cukup login
+func (p Permissions) forRequest(r *http.Request) string {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	for key, permission := range p {
+		method, action, ok := strings.Cut(key, " ")
+		if ok && method == r.Method && strings.HasSuffix(path, action) {
+			return permission
+		}
+	}
+	if permission, ok := p[r.Method]; ok {
+		return permission
+	}
+	return p["*"]
+}
+
+// Require - route yang butuh login dan permission, pengguna tanpa permission mendapat 403 JSON
+func (m *AuthMiddleware) Require(permissions Permissions, next http.HandlerFunc) http.HandlerFunc {
+	return m.Authenticated(func(w http.ResponseWriter, r *http.Request) {
+		permission := permissions.forRequest(r)
+		if permission != "" && !requirePermission(w, r, permission) {
+			return
+		}
+		next(w, r)
+	})
+}
+
+// requirePermission - cek permission pengguna yang login, false berarti response 403 sudah ditulis
+func requirePermission(w http.ResponseWriter, r *http.Request, permission string) bool {
+	user, ok := CurrentUser(r.Context())
+	if ok && user.Can(permission) {
+		return true
+	}
+	writeJSON(w, http.StatusForbidden, map[string]string{
+		"error":      "akses ditolak: butuh permission " + permission,
+		"permission": permission,
+	})
+	return false
+}
+
+// Authenticated - cek header Authorization: Bearer <access token>, pengguna yang login disimpan di context request
+func (m *AuthMiddleware) Authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kasir-api"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		user, sessionID, err := m.service.Authenticate(strings.TrimSpace(token))
+		if errors.Is(err, services.ErrInvalidToken) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kasir-api", error="invalid_token"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, user)
+		ctx = context.WithValue(ctx, sessionContextKey, sessionID)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// CurrentUser - pengguna yang login pada request, hanya tersedia di handler yang dibungkus Require / Authenticated
+func CurrentUser(ctx context.Context) (*models.User, bool) {
+	user, ok := ctx.Value(userContextKey).(*models.User)
+	return user, ok
+}
+
+// currentUserID - id pengguna yang login untuk dicatat pada dokumen, nil bila tidak ada
+func currentUserID(ctx context.Context) *int {
+	if user, ok := CurrentUser(ctx); ok {
+		return &user.ID
+	}
+	return nil
+}
+
+// currentSessionID - id sesi login dari access token, dipakai untuk logout
+func currentSessionID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(sessionContextKey).(int)
+	return id, ok
+}
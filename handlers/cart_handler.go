@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+)
+
+type CartHandler struct {
+	service *services.CartService
+	useLock bool
+}
+
+// NewCartHandler - useLock mengikuti mode checkout yang sama dengan /api/checkout
+func NewCartHandler(service *services.CartService, useLock bool) *CartHandler {
+	return &CartHandler{service: service, useLock: useLock}
+}
+
+// HandleCarts - GET /api/carts?status=, POST /api/carts
+func (h *CartHandler) HandleCarts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CartHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	carts, err := h.service.GetAll(r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(carts)
+}
+
+func (h *CartHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CartRequest
+	// body boleh kosong, label opsional
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	cart, err := h.service.Create(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, cart)
+}
+
+// HandleCartByID - GET /api/carts/{id}, POST /api/carts/{id}/items,
+// PUT/DELETE /api/carts/{id}/items/{product_id}, POST /api/carts/{id}/hold,
+// POST /api/carts/{id}/resume, POST /api/carts/{id}/checkout
+func (h *CartHandler) HandleCartByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/carts/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid cart ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	if action == "items" && len(parts) > 2 {
+		productID, err := strconv.Atoi(parts[2])
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			h.UpdateItem(w, r, id, productID)
+		case http.MethodDelete:
+			h.RemoveItem(w, r, id, productID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "items" && r.Method == http.MethodPost:
+		h.AddItem(w, r, id)
+	case action == "hold" && r.Method == http.MethodPost:
+		h.Hold(w, r, id)
+	case action == "resume" && r.Method == http.MethodPost:
+		h.Resume(w, r, id)
+	case action == "checkout" && r.Method == http.MethodPost:
+		h.Checkout(w, r, id)
+	case action == "" || action == "items" || action == "hold" || action == "resume" || action == "checkout":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *CartHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	cart, err := h.service.GetByID(id)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cart)
+}
+
+func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := h.service.AddItem(id, req)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request, id, productID int) {
+	var req models.CartItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	cart, err := h.service.UpdateItem(id, productID, req.Quantity)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request, id, productID int) {
+	cart, err := h.service.RemoveItem(id, productID)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) Hold(w http.ResponseWriter, r *http.Request, id int) {
+	cart, err := h.service.Hold(id)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cart)
+}
+
+func (h *CartHandler) Resume(w http.ResponseWriter, r *http.Request, id int) {
+	cart, err := h.service.Resume(id)
+	if err != nil {
+		writeCartError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cart)
+}
+
+// Checkout - finalisasi keranjang dengan logika checkout yang sama seperti /api/checkout
+func (h *CartHandler) Checkout(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.CartCheckoutRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	req.UserID = currentUserID(r.Context())
+	transaction, err := h.service.Finalize(id, req, h.useLock)
+	if errors.Is(err, repositories.ErrCartNotFound) || errors.Is(err, repositories.ErrCartStatus) {
+		writeCartError(w, err)
+		return
+	}
+
+	writeCheckoutResult(w, transaction, err)
+}
+
+// writeCartError - map error keranjang ke status code yang sesuai
+func writeCartError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrCartNotFound), errors.Is(err, repositories.ErrCartItemNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrCartStatus):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+)
+
+type CustomerHandler struct {
+	service *services.CustomerService
+}
+
+func NewCustomerHandler(service *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+// HandleCustomers - GET /api/customers?search=, POST /api/customers
+func (h *CustomerHandler) HandleCustomers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *CustomerHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	customers, err := h.service.GetAll(r.URL.Query().Get("search"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customers)
+}
+
+func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var customer models.Customer
+	err := json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Create(&customer)
+	if err != nil {
+		writeCustomerError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, customer)
+}
+
+// HandleCustomerByID - GET/PUT/DELETE /api/customers/{id}, GET /api/customers/{id}/transactions,
+// GET /api/customers/phone/{phone}
+func (h *CustomerHandler) HandleCustomerByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/customers/"), "/")
+
+	if parts[0] == "phone" && len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetByPhone(w, r, parts[1])
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid customer ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "" && r.Method == http.MethodPut:
+		h.Update(w, r, id)
+	case action == "" && r.Method == http.MethodDelete:
+		h.Delete(w, r, id)
+	case action == "transactions" && r.Method == http.MethodGet:
+		h.GetTransactions(w, r, id)
+	case action == "" || action == "transactions":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *CustomerHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	customer, err := h.service.GetByID(id)
+	if err != nil {
+		writeCustomerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+// GetByPhone - pencarian pelanggan oleh kasir berdasarkan nomor telepon
+func (h *CustomerHandler) GetByPhone(w http.ResponseWriter, r *http.Request, phone string) {
+	customer, err := h.service.GetByPhone(phone)
+	if err != nil {
+		writeCustomerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	var customer models.Customer
+	err := json.NewDecoder(r.Body).Decode(&customer)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	customer.ID = id
+	err = h.service.Update(&customer)
+	if err != nil {
+		writeCustomerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+func (h *CustomerHandler) Delete(w http.ResponseWriter, r *http.Request, id int) {
+	err := h.service.Delete(id)
+	if err != nil {
+		writeCustomerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pelanggan berhasil dihapus"})
+}
+
+// GetTransactions - riwayat pembelian pelanggan, query param sama seperti GET /api/transactions
+func (h *CustomerHandler) GetTransactions(w http.ResponseWriter, r *http.Request, id int) {
+	filter, err := parseTransactionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.GetTransactions(id, filter)
+	if err != nil {
+		writeCustomerError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeCustomerError - map error pelanggan ke status code yang sesuai
+func writeCustomerError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrCustomerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrCustomerPhoneTaken), errors.Is(err, repositories.ErrCustomerIsDebtor),
+		errors.Is(err, repositories.ErrCustomerHasPoints), errors.Is(err, repositories.ErrCustomerHasPointHistory):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
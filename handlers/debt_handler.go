@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+)
+
+type DebtHandler struct {
+	service *services.DebtService
+}
+
+func NewDebtHandler(service *services.DebtService) *DebtHandler {
+	return &DebtHandler{service: service}
+}
+
+// HandleDebtors - GET /api/debtors?outstanding=true, POST /api/debtors
+func (h *DebtHandler) HandleDebtors(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetAll - outstanding=true hanya debitur yang masih punya sisa kasbon
+func (h *DebtHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	outstandingOnly := r.URL.Query().Get("outstanding") == "true"
+	debtors, err := h.service.GetAll(outstandingOnly)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(debtors)
+}
+
+func (h *DebtHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.DebtorRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	debtor, err := h.service.Create(req)
+	if err != nil {
+		writeDebtError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, debtor)
+}
+
+// HandleDebtorByID - GET /api/debtors/aging, GET/PUT /api/debtors/{id},
+// GET /api/debtors/{id}/receivables?status=open, GET/POST /api/debtors/{id}/repayments
+func (h *DebtHandler) HandleDebtorByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/debtors/"), "/")
+
+	if parts[0] == "aging" && len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetAging(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid debtor ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "" && r.Method == http.MethodPut:
+		h.Update(w, r, id)
+	case action == "receivables" && r.Method == http.MethodGet:
+		h.GetReceivables(w, r, id)
+	case action == "repayments" && r.Method == http.MethodGet:
+		h.GetRepayments(w, r, id)
+	case action == "repayments" && r.Method == http.MethodPost:
+		h.CreateRepayment(w, r, id)
+	case action == "" || action == "receivables" || action == "repayments":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *DebtHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	debtor, err := h.service.GetByID(id)
+	if err != nil {
+		writeDebtError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(debtor)
+}
+
+func (h *DebtHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.DebtorRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	debtor, err := h.service.Update(id, req)
+	if err != nil {
+		writeDebtError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(debtor)
+}
+
+// GetReceivables - status=open hanya piutang yang belum lunas
+func (h *DebtHandler) GetReceivables(w http.ResponseWriter, r *http.Request, id int) {
+	openOnly := r.URL.Query().Get("status") == models.ReceivableStatusOpen
+	receivables, err := h.service.GetReceivables(id, openOnly)
+	if err != nil {
+		writeDebtError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receivables)
+}
+
+func (h *DebtHandler) GetRepayments(w http.ResponseWriter, r *http.Request, id int) {
+	repayments, err := h.service.GetRepayments(id)
+	if err != nil {
+		writeDebtError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(repayments)
+}
+
+// CreateRepayment - bayar cicilan kasbon sebagian atau seluruhnya
+func (h *DebtHandler) CreateRepayment(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.DebtRepaymentRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	repayment, err := h.service.CreateRepayment(id, req)
+	if err != nil {
+		writeDebtError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, repayment)
+}
+
+// GetAging - sisa kasbon per debitur dalam kelompok umur 0-30, 31-60 dan 60+ hari
+func (h *DebtHandler) GetAging(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetAging()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeDebtError - map error kasbon ke status code yang sesuai
+func writeDebtError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrDebtorNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrDebtorExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+)
+
+type GoodsReceiptHandler struct {
+	service *services.GoodsReceiptService
+}
+
+func NewGoodsReceiptHandler(service *services.GoodsReceiptService) *GoodsReceiptHandler {
+	return &GoodsReceiptHandler{service: service}
+}
+
+// HandleGoodsReceipts - GET /api/goods-receipts?start_date=&end_date=&supplier_id=&supplier=&purchase_order_id=&status=,
+// POST /api/goods-receipts (posting penerimaan barang)
+func (h *GoodsReceiptHandler) HandleGoodsReceipts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *GoodsReceiptHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.GoodsReceiptFilter{Supplier: query.Get("supplier"), Status: query.Get("status")}
+
+	var err error
+	if filter.StartDate, err = parseDateParam(query.Get("start_date")); err != nil {
+		http.Error(w, "Invalid start_date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if filter.EndDate, err = parseDateParam(query.Get("end_date")); err != nil {
+		http.Error(w, "Invalid end_date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	// end_date inklusif, mencakup seluruh hari
+	if filter.EndDate != nil {
+		endDate := filter.EndDate.Add(24 * time.Hour)
+		filter.EndDate = &endDate
+	}
+	if value := query.Get("supplier_id"); value != "" {
+		supplierID, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid supplier_id", http.StatusBadRequest)
+			return
+		}
+		filter.SupplierID = &supplierID
+	}
+	if value := query.Get("purchase_order_id"); value != "" {
+		purchaseOrderID, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid purchase_order_id", http.StatusBadRequest)
+			return
+		}
+		filter.PurchaseOrderID = &purchaseOrderID
+	}
+
+	receipts, err := h.service.GetAll(filter)
+	if err != nil {
+		writeGoodsReceiptError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipts)
+}
+
+func (h *GoodsReceiptHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.GoodsReceiptRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = currentUserID(r.Context())
+
+	receipt, err := h.service.Create(req)
+	if err != nil {
+		writeGoodsReceiptError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, receipt)
+}
+
+// HandleGoodsReceiptByID - GET /api/goods-receipts/{id}, POST /api/goods-receipts/{id}/reverse
+func (h *GoodsReceiptHandler) HandleGoodsReceiptByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/goods-receipts/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid goods receipt ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "reverse" && r.Method == http.MethodPost:
+		h.Reverse(w, r, id)
+	case action == "" || action == "reverse":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *GoodsReceiptHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	receipt, err := h.service.GetByID(id)
+	if err != nil {
+		writeGoodsReceiptError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipt)
+}
+
+func (h *GoodsReceiptHandler) Reverse(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.ReverseGoodsReceiptRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	req.UserID = currentUserID(r.Context())
+
+	receipt, err := h.service.Reverse(id, req)
+	if err != nil {
+		writeGoodsReceiptError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipt)
+}
+
+// writeGoodsReceiptError - map error penerimaan barang ke status code yang sesuai
+func writeGoodsReceiptError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrGoodsReceiptNotFound), errors.Is(err, repositories.ErrPurchaseOrderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrGoodsReceiptReversed), errors.Is(err, repositories.ErrPurchaseOrderStatus):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"kasir-api/repositories"
+	"kasir-api/services"
+)
+
+type LoyaltyHandler struct {
+	service *services.LoyaltyService
+}
+
+func NewLoyaltyHandler(service *services.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{service: service}
+}
+
+// HandleLoyalty - GET /api/loyalty/{phone} (saldo poin), GET /api/loyalty/{phone}/history?limit=
+func (h *LoyaltyHandler) HandleLoyalty(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/loyalty/"), "/")
+	phone := parts[0]
+	if phone == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetBalance(w, r, phone)
+	case action == "history" && r.Method == http.MethodGet:
+		h.GetHistory(w, r, phone)
+	case action == "" || action == "history":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *LoyaltyHandler) GetBalance(w http.ResponseWriter, r *http.Request, phone string) {
+	balance, err := h.service.GetBalance(phone)
+	if errors.Is(err, repositories.ErrCustomerNotFound) {
+		http.Error(w, "member tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(balance)
+}
+
+func (h *LoyaltyHandler) GetHistory(w http.ResponseWriter, r *http.Request, phone string) {
+	limit, err := parseIntParam(r.URL.Query().Get("limit"))
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	if limit == nil {
+		limit = new(int)
+	}
+
+	history, err := h.service.GetHistory(phone, *limit)
+	if errors.Is(err, repositories.ErrCustomerNotFound) {
+		http.Error(w, "member tidak ditemukan", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+)
+
+type OpnameHandler struct {
+	service *services.OpnameService
+}
+
+func NewOpnameHandler(service *services.OpnameService) *OpnameHandler {
+	return &OpnameHandler{service: service}
+}
+
+// HandleOpnames - GET /api/stock/opnames?status=, POST /api/stock/opnames (buka sesi dan snapshot stok)
+func (h *OpnameHandler) HandleOpnames(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *OpnameHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	opnames, err := h.service.GetAll(r.URL.Query().Get("status"))
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opnames)
+}
+
+func (h *OpnameHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.StockOpnameRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	req.UserID = currentUserID(r.Context())
+
+	opname, err := h.service.Create(req)
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, opname)
+}
+
+// HandleOpnameByID - GET /api/stock/opnames/{id} (variance per produk), POST /api/stock/opnames/{id}/counts,
+// POST /api/stock/opnames/{id}/approve, POST /api/stock/opnames/{id}/cancel
+func (h *OpnameHandler) HandleOpnameByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/stock/opnames/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid opname ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "counts" && r.Method == http.MethodPost:
+		h.AddCounts(w, r, id)
+	case action == "approve" && r.Method == http.MethodPost:
+		h.Approve(w, r, id)
+	case action == "cancel" && r.Method == http.MethodPost:
+		h.Cancel(w, r, id)
+	case action == "" || action == "counts" || action == "approve" || action == "cancel":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *OpnameHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	opname, err := h.service.GetByID(id)
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opname)
+}
+
+// AddCounts - counter kosong memakai username yang login
+func (h *OpnameHandler) AddCounts(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.StockOpnameCountRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if user, ok := CurrentUser(r.Context()); ok {
+		req.UserID = &user.ID
+		if strings.TrimSpace(req.Counter) == "" {
+			req.Counter = user.Username
+		}
+	}
+
+	opname, err := h.service.AddCounts(id, req)
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opname)
+}
+
+func (h *OpnameHandler) Approve(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.ApproveOpnameRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	req.UserID = currentUserID(r.Context())
+
+	opname, err := h.service.Approve(id, req)
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opname)
+}
+
+func (h *OpnameHandler) Cancel(w http.ResponseWriter, r *http.Request, id int) {
+	opname, err := h.service.Cancel(id)
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opname)
+}
+
+// writeOpnameError - map error stock opname ke status code yang sesuai
+func writeOpnameError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrOpnameNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrOpnameAlreadyOpen), errors.Is(err, repositories.ErrOpnameClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+	"net/http"
+)
+
+// maxWebhookBody - batas ukuran body callback payment gateway
+const maxWebhookBody = 1 << 20
+
+type PaymentHandler struct {
+	service *services.PaymentService
+}
+
+func NewPaymentHandler(service *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+// HandleWebhook - POST /api/payments/webhook, callback payment gateway dengan header X-Signature
+func (h *PaymentHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	intent, err := h.service.HandleCallback(body, r.Header.Get("X-Signature"))
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, intent)
+}
+
+// HandleFakePay - POST /api/payments/fake/pay?external_id=...&status=paid|failed
+// simulasi callback FakePaymentGateway untuk testing lokal, hanya didaftarkan saat PAYMENT_SANDBOX=true
+func (h *PaymentHandler) HandleFakePay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	gateway, ok := h.service.Gateway().(*services.FakePaymentGateway)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	externalID := r.URL.Query().Get("external_id")
+	if externalID == "" {
+		http.Error(w, "external_id is required", http.StatusBadRequest)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.PaymentStatusPaid
+	}
+
+	body, signature, err := gateway.SimulateCallback(externalID, status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	intent, err := h.service.HandleCallback(body, signature)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, intent)
+}
+
+// writePaymentError - map error callback pembayaran ke status code yang sesuai
+func writePaymentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSignature):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, repositories.ErrPaymentIntentNotFound), errors.Is(err, services.ErrPaymentGatewayDisabled):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrPaymentIntentClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
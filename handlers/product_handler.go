@@ -2,19 +2,23 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"kasir-api/models"
+	"kasir-api/repositories"
 	"kasir-api/services"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ProductHandler struct {
 	service *services.ProductService
+	stock   *services.StockService
 }
 
-func NewProductHandler(service *services.ProductService) *ProductHandler {
-	return &ProductHandler{service: service}
+func NewProductHandler(service *services.ProductService, stock *services.StockService) *ProductHandler {
+	return &ProductHandler{service: service, stock: stock}
 }
 
 // HandleProducts - GET /api/produk
@@ -51,7 +55,7 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Create(&product)
+	err = h.service.Create(&product, currentUserID(r.Context()))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -62,8 +66,26 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(product)
 }
 
-// HandleProductByID - GET/PUT/DELETE /api/produk/{id}
+// HandleProductByID - GET/PUT/DELETE /api/produk/{id}, GET /api/product/{id}/movements
 func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Request) {
+	if parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/product/"), "/"); len(parts) > 1 {
+		id, err := strconv.Atoi(parts[0])
+		if err != nil {
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+
+		switch {
+		case parts[1] == "movements" && len(parts) == 2 && r.Method == http.MethodGet:
+			h.GetMovements(w, r, id)
+		case parts[1] == "movements" && len(parts) == 2:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetByID(w, r)
@@ -141,3 +163,46 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		"message": "Product deleted successfully",
 	})
 }
+
+// GetMovements - GET /api/product/{id}/movements?type=&start_date=&end_date=, kartu stok produk
+func (h *ProductHandler) GetMovements(w http.ResponseWriter, r *http.Request, id int) {
+	query := r.URL.Query()
+	filter := models.StockMovementFilter{Type: query.Get("type")}
+
+	var err error
+	if filter.StartDate, err = parseDateParam(query.Get("start_date")); err != nil {
+		http.Error(w, "Invalid start_date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if filter.EndDate, err = parseDateParam(query.Get("end_date")); err != nil {
+		http.Error(w, "Invalid end_date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	// end_date inklusif, mencakup seluruh hari
+	if filter.EndDate != nil {
+		endDate := filter.EndDate.Add(24 * time.Hour)
+		filter.EndDate = &endDate
+	}
+
+	history, err := h.stock.GetHistory(id, filter)
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// writeStockError - map error stok ke status code yang sesuai
+func writeStockError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrProductNotFound), errors.Is(err, repositories.ErrStockAdjustmentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
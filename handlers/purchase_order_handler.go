@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+)
+
+type PurchaseOrderHandler struct {
+	service *services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+// HandlePurchaseOrders - GET /api/purchase-orders?status=&supplier_id=, POST /api/purchase-orders (draft baru)
+func (h *PurchaseOrderHandler) HandlePurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PurchaseOrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.PurchaseOrderFilter{Status: query.Get("status")}
+	if value := query.Get("supplier_id"); value != "" {
+		supplierID, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid supplier_id", http.StatusBadRequest)
+			return
+		}
+		filter.SupplierID = &supplierID
+	}
+
+	orders, err := h.service.GetAll(filter)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
+
+func (h *PurchaseOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.PurchaseOrderRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = currentUserID(r.Context())
+
+	order, err := h.service.Create(req)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, order)
+}
+
+// HandlePurchaseOrderByID - GET/PUT /api/purchase-orders/{id}, POST /api/purchase-orders/{id}/send,
+// POST /api/purchase-orders/{id}/receive, POST /api/purchase-orders/{id}/cancel
+func (h *PurchaseOrderHandler) HandlePurchaseOrderByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/purchase-orders/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid purchase order ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "" && r.Method == http.MethodPut:
+		h.Update(w, r, id)
+	case action == "send" && r.Method == http.MethodPost:
+		h.Send(w, r, id)
+	case action == "receive" && r.Method == http.MethodPost:
+		h.Receive(w, r, id)
+	case action == "cancel" && r.Method == http.MethodPost:
+		h.Cancel(w, r, id)
+	case action == "" || action == "send" || action == "receive" || action == "cancel":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *PurchaseOrderHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	order, err := h.service.GetByID(id)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.PurchaseOrderRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.service.Update(id, req)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *PurchaseOrderHandler) Send(w http.ResponseWriter, r *http.Request, id int) {
+	order, err := h.service.Send(id)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// Receive - barang yang datang dicatat sebagai goods receipt, response berisi dokumen penerimaan
+func (h *PurchaseOrderHandler) Receive(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.ReceivePurchaseOrderRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = currentUserID(r.Context())
+
+	receipt, err := h.service.Receive(id, req)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, receipt)
+}
+
+func (h *PurchaseOrderHandler) Cancel(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.CancelPurchaseOrderRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	order, err := h.service.Cancel(id, req)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
+// writePurchaseError - map error supplier / purchase order ke status code yang sesuai
+func writePurchaseError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrSupplierNotFound), errors.Is(err, repositories.ErrPurchaseOrderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrSupplierNameTaken), errors.Is(err, repositories.ErrPurchaseOrderStatus):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -2,8 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
 	"kasir-api/services"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -15,15 +19,21 @@ func NewReportHandler(service *services.ReportService) *ReportHandler {
 	return &ReportHandler{service: service}
 }
 
-// function untuk menangani laporan harian
+// function untuk menangani laporan harian, ?customer_id= untuk laporan satu pelanggan
 func (h *ReportHandler) HandleDailyReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	customerID, err := parseIntParam(r.URL.Query().Get("customer_id"))
+	if err != nil {
+		http.Error(w, "Invalid customer_id", http.StatusBadRequest)
+		return
+	}
+
 	// panggil service untuk mendapatkan laporan hari ini
-	report, err := h.service.GetDailyReport()
+	report, err := h.service.GetDailyReport(customerID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -78,8 +88,14 @@ func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	customerID, err := parseIntParam(r.URL.Query().Get("customer_id"))
+	if err != nil {
+		http.Error(w, "Invalid customer_id", http.StatusBadRequest)
+		return
+	}
+
 	// panggil service untuk mendapatkan laporan berdasarkan range tanggal startDate dan endDate
-	report, err := h.service.GetReportByDateRange(startDate, endDate)
+	report, err := h.service.GetReportByDateRange(models.ReportFilter{StartDate: startDate, EndDate: endDate, CustomerID: customerID})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -88,3 +104,102 @@ func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(report)
 }
+
+// HandleXReport - GET /api/report/x?date=YYYY-MM-DD, snapshot berjalan (default hari ini)
+func (h *ReportHandler) HandleXReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	date, err := parseBusinessDate(r.URL.Query().Get("date"))
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetXReport(date)
+	if err != nil {
+		writeEndOfDayError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleZReports - GET /api/report/z (daftar laporan Z), POST /api/report/z?date=YYYY-MM-DD (tutup hari, default hari ini)
+func (h *ReportHandler) HandleZReports(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		reports, err := h.service.GetZReports()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reports)
+	case http.MethodPost:
+		date, err := parseBusinessDate(r.URL.Query().Get("date"))
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		report, err := h.service.GenerateZReport(date)
+		if err != nil {
+			writeEndOfDayError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, report)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleZReportByDate - GET /api/report/z/{YYYY-MM-DD}
+func (h *ReportHandler) HandleZReportByDate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	date, err := parseBusinessDate(strings.TrimPrefix(r.URL.Path, "/api/report/z/"))
+	if err != nil {
+		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetZReport(date.Format("2006-01-02"))
+	if err != nil {
+		writeEndOfDayError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// parseBusinessDate - tanggal bisnis dalam zona waktu lokal server, string kosong berarti hari ini
+func parseBusinessDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// writeEndOfDayError - map error laporan X / Z ke status code yang sesuai
+func writeEndOfDayError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrZReportNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrDaySealed), errors.Is(err, services.ErrPendingTransactions):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, services.ErrFutureBusinessDate):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"kasir-api/services"
+)
+
+type ShiftHandler struct {
+	service *services.ShiftService
+}
+
+func NewShiftHandler(service *services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{service: service}
+}
+
+// HandleShifts - GET /api/shifts, POST /api/shifts (buka shift)
+func (h *ShiftHandler) HandleShifts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Open(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *ShiftHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	shifts, err := h.service.GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shifts)
+}
+
+func (h *ShiftHandler) Open(w http.ResponseWriter, r *http.Request) {
+	var req models.OpenShiftRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// nama kasir default dari pengguna yang login
+	if user, ok := CurrentUser(r.Context()); ok && strings.TrimSpace(req.CashierName) == "" {
+		req.CashierName = user.Name
+	}
+
+	shift, err := h.service.Open(req)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, shift)
+}
+
+// HandleShiftByID - GET /api/shifts/current, GET /api/shifts/{id} (laporan shift),
+// POST /api/shifts/{id}/cash-movements, POST /api/shifts/{id}/close
+func (h *ShiftHandler) HandleShiftByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/shifts/"), "/")
+
+	if parts[0] == "current" && len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetCurrent(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid shift ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetReport(w, r, id)
+	case action == "cash-movements" && r.Method == http.MethodPost:
+		h.AddCashMovement(w, r, id)
+	case action == "close" && r.Method == http.MethodPost:
+		h.Close(w, r, id)
+	case action == "" || action == "cash-movements" || action == "close":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// GetCurrent - laporan berjalan shift yang sedang open
+func (h *ShiftHandler) GetCurrent(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetCurrent()
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ShiftHandler) GetReport(w http.ResponseWriter, r *http.Request, id int) {
+	report, err := h.service.GetReport(id)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ShiftHandler) AddCashMovement(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.CashMovementRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	movement, err := h.service.AddCashMovement(id, req)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, movement)
+}
+
+// Close - tutup shift dengan counted_cash, response berisi laporan shift
+func (h *ShiftHandler) Close(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.CloseShiftRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.Close(id, req)
+	if err != nil {
+		writeShiftError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// writeShiftError - map error shift ke status code yang sesuai
+func writeShiftError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrShiftNotFound), errors.Is(err, repositories.ErrNoOpenShift):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrShiftAlreadyOpen), errors.Is(err, repositories.ErrShiftClosed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
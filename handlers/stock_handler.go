@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kasir-api/models"
+	"kasir-api/services"
+)
+
+type StockHandler struct {
+	service *services.StockService
+}
+
+func NewStockHandler(service *services.StockService) *StockHandler {
+	return &StockHandler{service: service}
+}
+
+// HandleAdjustments - GET /api/stock/adjustments?start_date=&end_date=&page=&limit=, POST /api/stock/adjustments
+func (h *StockHandler) HandleAdjustments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAdjustments(w, r)
+	case http.MethodPost:
+		h.CreateAdjustment(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *StockHandler) GetAdjustments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter models.StockAdjustmentFilter
+	var err error
+	if filter.StartDate, err = parseDateParam(query.Get("start_date")); err != nil {
+		http.Error(w, "Invalid start_date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	if filter.EndDate, err = parseDateParam(query.Get("end_date")); err != nil {
+		http.Error(w, "Invalid end_date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	// end_date inklusif, mencakup seluruh hari
+	if filter.EndDate != nil {
+		endDate := filter.EndDate.Add(24 * time.Hour)
+		filter.EndDate = &endDate
+	}
+	page, err := parseIntParam(query.Get("page"))
+	if err != nil {
+		http.Error(w, "Invalid page", http.StatusBadRequest)
+		return
+	}
+	if page != nil {
+		filter.Page = *page
+	}
+	limit, err := parseIntParam(query.Get("limit"))
+	if err != nil {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+
+	result, err := h.service.GetAdjustments(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// CreateAdjustment - seluruh baris diterapkan atau tidak sama sekali
+func (h *StockHandler) CreateAdjustment(w http.ResponseWriter, r *http.Request) {
+	var req models.StockAdjustmentRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.UserID = currentUserID(r.Context())
+
+	adjustment, err := h.service.CreateAdjustment(req)
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, adjustment)
+}
+
+// HandleAdjustmentByID - GET /api/stock/adjustments/{id}
+func (h *StockHandler) HandleAdjustmentByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/stock/adjustments/"))
+	if err != nil {
+		http.Error(w, "Invalid adjustment ID", http.StatusBadRequest)
+		return
+	}
+
+	adjustment, err := h.service.GetAdjustment(id)
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(adjustment)
+}
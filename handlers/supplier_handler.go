@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kasir-api/models"
+	"kasir-api/services"
+)
+
+type SupplierHandler struct {
+	service *services.SupplierService
+}
+
+func NewSupplierHandler(service *services.SupplierService) *SupplierHandler {
+	return &SupplierHandler{service: service}
+}
+
+// HandleSuppliers - GET /api/suppliers?search=, POST /api/suppliers
+func (h *SupplierHandler) HandleSuppliers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	case http.MethodPost:
+		h.Create(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *SupplierHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := h.service.GetAll(r.URL.Query().Get("search"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suppliers)
+}
+
+func (h *SupplierHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.SupplierRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := h.service.Create(req)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, supplier)
+}
+
+// HandleSupplierByID - GET/PUT /api/suppliers/{id}, GET /api/suppliers/{id}/purchase-orders?status=
+func (h *SupplierHandler) HandleSupplierByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/suppliers/"), "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid supplier ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "" && r.Method == http.MethodPut:
+		h.Update(w, r, id)
+	case action == "purchase-orders" && r.Method == http.MethodGet:
+		h.GetPurchaseOrders(w, r, id)
+	case action == "" || action == "purchase-orders":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *SupplierHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	supplier, err := h.service.GetByID(id)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(supplier)
+}
+
+func (h *SupplierHandler) Update(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.SupplierRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	supplier, err := h.service.Update(id, req)
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(supplier)
+}
+
+// GetPurchaseOrders - tanpa status hanya purchase order yang masih open
+func (h *SupplierHandler) GetPurchaseOrders(w http.ResponseWriter, r *http.Request, id int) {
+	orders, err := h.service.GetPurchaseOrders(id, r.URL.Query().Get("status"))
+	if err != nil {
+		writePurchaseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(orders)
+}
@@ -2,18 +2,30 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"kasir-api/models"
+	"kasir-api/repositories"
 	"kasir-api/services"
 )
 
 type TransactionHandler struct {
-	service *services.TransactionService
+	service     *services.TransactionService
+	idempotency *services.IdempotencyService
+	receipt     *services.ReceiptService
+	useLock     bool
 }
 
-func NewTransactionHandler(service *services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{service: service}
+// NewTransactionHandler - useLock menentukan mode checkout, pessimistic (true) atau optimistic (false)
+func NewTransactionHandler(service *services.TransactionService, idempotency *services.IdempotencyService, receipt *services.ReceiptService, useLock bool) *TransactionHandler {
+	return &TransactionHandler{service: service, idempotency: idempotency, receipt: receipt, useLock: useLock}
 }
 
 // multiple item apa aja, quantity nya
@@ -26,6 +38,8 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// Checkout - dengan header Idempotency-Key (per pengguna), request ulang dengan body yang sama
+// mendapat response asli tanpa membuat transaksi baru
 func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	var req models.CheckoutRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -33,8 +47,246 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	req.UserID = currentUserID(r.Context())
 
-	transaction, err := h.service.Checkout(req.Items, false)
+	key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	if key == "" {
+		h.checkout(w, req)
+		return
+	}
+	if len(key) > 255 {
+		http.Error(w, "Idempotency-Key too long", http.StatusBadRequest)
+		return
+	}
+
+	// hash dari request yang sudah di-decode agar perbedaan spasi / urutan field tidak berpengaruh
+	normalized, err := json.Marshal(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	record, err := h.idempotency.Begin(req.UserID, key, services.HashRequest(normalized))
+	if errors.Is(err, services.ErrIdempotencyKeyMismatch) || errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if record != nil {
+		w.Header().Set("Idempotent-Replayed", "true")
+		// transaksi sudah tersimpan tetapi response-nya belum sempat dicatat
+		if record.ResponseBody == nil && record.TransactionID != nil {
+			transaction, err := h.service.GetByID(*record.TransactionID)
+			writeCheckoutResult(w, transaction, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(record.ResponseStatus)
+		w.Write(record.ResponseBody)
+		return
+	}
+
+	// key ditandai completed di dalam transaksi database checkout, response dicatat setelahnya
+	req.IdempotencyKey = key
+	recorder := newResponseRecorder(w)
+	transaction := h.checkout(recorder, req)
+
+	// error server dan konflik stok sebelum transaksi tersimpan tidak disimpan agar client bisa mencoba lagi.
+	// Transaksi yang sudah tersimpan (misalnya payment gateway gagal setelah commit) selalu dicatat
+	// bersama response-nya, request ulang mendapat response yang sama.
+	if transaction == nil && (recorder.status >= http.StatusInternalServerError || recorder.status == http.StatusConflict) {
+		if err := h.idempotency.Release(req.UserID, key); err != nil {
+			log.Println("Failed to release idempotency key:", err)
+		}
+		return
+	}
+
+	var transactionID *int
+	if transaction != nil {
+		transactionID = &transaction.ID
+	}
+	if err := h.idempotency.Complete(req.UserID, key, recorder.status, recorder.body.Bytes(), transactionID); err != nil {
+		log.Println("Failed to store idempotency key:", err)
+	}
+}
+
+// checkout - proses checkout dan tulis response, mengembalikan transaksi bila sudah tersimpan
+func (h *TransactionHandler) checkout(w http.ResponseWriter, req models.CheckoutRequest) *models.Transaction {
+	transaction, err := h.service.Checkout(req, h.useLock)
+	return writeCheckoutResult(w, transaction, err)
+}
+
+// writeCheckoutResult - map hasil checkout ke response, dipakai checkout langsung maupun checkout keranjang.
+// Transaksi yang sudah tersimpan tetap dikembalikan walaupun payment gateway gagal.
+func writeCheckoutResult(w http.ResponseWriter, transaction *models.Transaction, err error) *models.Transaction {
+	var validationErr *models.ValidationError
+	if errors.As(err, &validationErr) {
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+		return nil
+	}
+	var stockErr *models.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		writeJSON(w, http.StatusConflict, map[string]interface{}{
+			"error": "stok tidak mencukupi",
+			"items": stockErr.Items,
+		})
+		return nil
+	}
+	if errors.Is(err, repositories.ErrStockConflict) || errors.Is(err, repositories.ErrNoOpenShift) || errors.Is(err, repositories.ErrDaySealed) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return nil
+	}
+	if errors.Is(err, services.ErrPaymentGateway) {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return transaction
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return transaction
+	}
+
+	// transaksi QRIS / e-wallet masih menunggu callback payment gateway
+	if transaction.Status == models.TransactionStatusPending {
+		writeJSON(w, http.StatusAccepted, transaction)
+		return transaction
+	}
+
+	writeJSON(w, http.StatusOK, transaction)
+	return transaction
+}
+
+// HandleTransactions - GET /api/transactions?start_date=&end_date=&min_amount=&max_amount=&product_id=&customer_id=&shift_id=&invoice_number=&page=&limit=
+func (h *TransactionHandler) HandleTransactions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.GetAll(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *TransactionHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTransactionFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.GetAll(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// parseTransactionFilter - parsing query param filter daftar transaksi, dipakai juga riwayat pelanggan
+func parseTransactionFilter(query url.Values) (models.TransactionFilter, error) {
+	var filter models.TransactionFilter
+	var err error
+
+	if filter.StartDate, err = parseDateParam(query.Get("start_date")); err != nil {
+		return filter, errors.New("Invalid start_date format. Use YYYY-MM-DD")
+	}
+	if filter.EndDate, err = parseDateParam(query.Get("end_date")); err != nil {
+		return filter, errors.New("Invalid end_date format. Use YYYY-MM-DD")
+	}
+	// end_date inklusif, mencakup seluruh hari
+	if filter.EndDate != nil {
+		endDate := filter.EndDate.Add(24 * time.Hour)
+		filter.EndDate = &endDate
+	}
+	if filter.MinAmount, err = parseIntParam(query.Get("min_amount")); err != nil {
+		return filter, errors.New("Invalid min_amount")
+	}
+	if filter.MaxAmount, err = parseIntParam(query.Get("max_amount")); err != nil {
+		return filter, errors.New("Invalid max_amount")
+	}
+	if filter.ProductID, err = parseIntParam(query.Get("product_id")); err != nil {
+		return filter, errors.New("Invalid product_id")
+	}
+	if filter.CustomerID, err = parseIntParam(query.Get("customer_id")); err != nil {
+		return filter, errors.New("Invalid customer_id")
+	}
+	if filter.ShiftID, err = parseIntParam(query.Get("shift_id")); err != nil {
+		return filter, errors.New("Invalid shift_id")
+	}
+	filter.InvoiceNumber = strings.TrimSpace(query.Get("invoice_number"))
+
+	page, err := parseIntParam(query.Get("page"))
+	if err != nil {
+		return filter, errors.New("Invalid page")
+	}
+	if page != nil {
+		filter.Page = *page
+	}
+	limit, err := parseIntParam(query.Get("limit"))
+	if err != nil {
+		return filter, errors.New("Invalid limit")
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+
+	return filter, nil
+}
+
+// HandleTransactionByID - GET /api/transactions/{id}, POST /api/transactions/{id}/void,
+// POST /api/transactions/{id}/refund, GET /api/transactions/{id}/refunds,
+// GET /api/transactions/{id}/receipt, GET /api/transactions/invoice/{invoice_number}
+func (h *TransactionHandler) HandleTransactionByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/transactions/")
+	// nomor invoice mengandung "/", sisa path setelah invoice/ adalah nomornya
+	if invoiceNumber, ok := strings.CutPrefix(path, "invoice/"); ok {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetByInvoiceNumber(w, r, invoiceNumber)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	action := ""
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.GetByID(w, r, id)
+	case action == "void" && r.Method == http.MethodPost:
+		h.Void(w, r, id)
+	case action == "refund" && r.Method == http.MethodPost:
+		h.Refund(w, r, id)
+	case action == "refunds" && r.Method == http.MethodGet:
+		h.GetRefunds(w, r, id)
+	case action == "receipt" && r.Method == http.MethodGet:
+		h.Receipt(w, r, id)
+	case action == "" || action == "void" || action == "refund" || action == "refunds" || action == "receipt":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *TransactionHandler) GetByID(w http.ResponseWriter, r *http.Request, id int) {
+	transaction, err := h.service.GetByID(id)
+	if errors.Is(err, repositories.ErrTransactionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -42,4 +294,122 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(transaction)
-}
\ No newline at end of file
+}
+
+func (h *TransactionHandler) GetByInvoiceNumber(w http.ResponseWriter, r *http.Request, invoiceNumber string) {
+	transaction, err := h.service.GetByInvoiceNumber(invoiceNumber)
+	if errors.Is(err, repositories.ErrTransactionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *TransactionHandler) Void(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.RefundRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.UserID = currentUserID(r.Context())
+	refund, err := h.service.Void(id, req)
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, refund)
+}
+
+func (h *TransactionHandler) Refund(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.RefundRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	req.UserID = currentUserID(r.Context())
+	refund, err := h.service.Refund(id, req)
+	if err != nil {
+		writeRefundError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, refund)
+}
+
+func (h *TransactionHandler) GetRefunds(w http.ResponseWriter, r *http.Request, id int) {
+	refunds, err := h.service.GetRefunds(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refunds)
+}
+
+// Receipt - GET /api/transactions/{id}/receipt?format=text|escpos|pdf&width=58|80, default text 80mm
+func (h *TransactionHandler) Receipt(w http.ResponseWriter, r *http.Request, id int) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = models.ReceiptFormatText
+	}
+	width := 80
+	if value := query.Get("width"); value != "" {
+		n, err := strconv.Atoi(strings.TrimSuffix(value, "mm"))
+		if err != nil {
+			http.Error(w, "Invalid width", http.StatusBadRequest)
+			return
+		}
+		width = n
+	}
+
+	receipt, err := h.receipt.Render(id, format, width)
+	switch {
+	case errors.Is(err, services.ErrUnsupportedReceiptFormat), errors.Is(err, services.ErrUnsupportedPaperWidth):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, repositories.ErrTransactionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch format {
+	case models.ReceiptFormatPDF:
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"receipt-%d.pdf\"", id))
+	case models.ReceiptFormatESCPOS:
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"receipt-%d.bin\"", id))
+	}
+	w.Header().Set("Content-Type", receipt.ContentType)
+	w.Write(receipt.Body)
+}
+
+// writeRefundError - map error void / refund ke status code yang sesuai
+func writeRefundError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeJSON(w, http.StatusUnprocessableEntity, validationErr)
+	case errors.Is(err, repositories.ErrTransactionNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repositories.ErrTransactionVoided), errors.Is(err, repositories.ErrNothingToRefund), errors.Is(err, repositories.ErrTransactionNotPaid),
+		errors.Is(err, repositories.ErrDaySealed):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
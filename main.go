@@ -7,10 +7,12 @@ import (
 	"log"
 	"strings"
 	"encoding/json"
+	"time"
 
 	"kasir-api/repositories"
 	"kasir-api/config"
 	"kasir-api/handlers"
+	"kasir-api/models"
 	"kasir-api/services"
 
 	"github.com/spf13/viper"
@@ -19,6 +21,27 @@ import (
 type Config struct {
 	Port string `mapstructure:"PORT"`
 	DBConnectionString string `mapstructure:"DB_CONN"`
+	CheckoutLockMode string `mapstructure:"CHECKOUT_LOCK_MODE"`
+	PaymentSandbox bool `mapstructure:"PAYMENT_SANDBOX"`
+	PaymentWebhookSecret string `mapstructure:"PAYMENT_WEBHOOK_SECRET"`
+	PaymentIntentTTLMinutes int `mapstructure:"PAYMENT_INTENT_TTL_MINUTES"`
+	IdempotencyRetentionHours int `mapstructure:"IDEMPOTENCY_RETENTION_HOURS"`
+	CartTTLHours int `mapstructure:"CART_TTL_HOURS"`
+	InvoiceOutletCode string `mapstructure:"INVOICE_OUTLET_CODE"`
+	InvoiceResetPeriod string `mapstructure:"INVOICE_RESET_PERIOD"`
+	ReceiptShopName string `mapstructure:"RECEIPT_SHOP_NAME"`
+	ReceiptShopAddress string `mapstructure:"RECEIPT_SHOP_ADDRESS"`
+	ReceiptShopPhone string `mapstructure:"RECEIPT_SHOP_PHONE"`
+	ReceiptFooter string `mapstructure:"RECEIPT_FOOTER"`
+	LoyaltyEarnRupiahPerPoint int `mapstructure:"LOYALTY_EARN_RUPIAH_PER_POINT"`
+	LoyaltyPointValue int `mapstructure:"LOYALTY_POINT_VALUE"`
+	LoyaltyPointExpiryDays int `mapstructure:"LOYALTY_POINT_EXPIRY_DAYS"`
+	ShiftRequired bool `mapstructure:"SHIFT_REQUIRED"`
+	JWTSecret string `mapstructure:"JWT_SECRET"`
+	AccessTokenTTLMinutes int `mapstructure:"ACCESS_TOKEN_TTL_MINUTES"`
+	RefreshTokenTTLHours int `mapstructure:"REFRESH_TOKEN_TTL_HOURS"`
+	AuthAdminUsername string `mapstructure:"AUTH_ADMIN_USERNAME"`
+	AuthAdminPassword string `mapstructure:"AUTH_ADMIN_PASSWORD"`
 }
 
 func main() {
@@ -34,6 +57,76 @@ func main() {
 	configEnv := Config{
 		Port:  viper.GetString("PORT"),
 		DBConnectionString: viper.GetString("DB_CONN"),
+		CheckoutLockMode: viper.GetString("CHECKOUT_LOCK_MODE"),
+		PaymentSandbox: viper.GetBool("PAYMENT_SANDBOX"),
+		PaymentWebhookSecret: viper.GetString("PAYMENT_WEBHOOK_SECRET"),
+		PaymentIntentTTLMinutes: viper.GetInt("PAYMENT_INTENT_TTL_MINUTES"),
+		IdempotencyRetentionHours: viper.GetInt("IDEMPOTENCY_RETENTION_HOURS"),
+		CartTTLHours: viper.GetInt("CART_TTL_HOURS"),
+		InvoiceOutletCode: viper.GetString("INVOICE_OUTLET_CODE"),
+		InvoiceResetPeriod: viper.GetString("INVOICE_RESET_PERIOD"),
+		ReceiptShopName: viper.GetString("RECEIPT_SHOP_NAME"),
+		ReceiptShopAddress: viper.GetString("RECEIPT_SHOP_ADDRESS"),
+		ReceiptShopPhone: viper.GetString("RECEIPT_SHOP_PHONE"),
+		ReceiptFooter: viper.GetString("RECEIPT_FOOTER"),
+		LoyaltyEarnRupiahPerPoint: viper.GetInt("LOYALTY_EARN_RUPIAH_PER_POINT"),
+		LoyaltyPointValue: viper.GetInt("LOYALTY_POINT_VALUE"),
+		LoyaltyPointExpiryDays: viper.GetInt("LOYALTY_POINT_EXPIRY_DAYS"),
+		ShiftRequired: viper.GetBool("SHIFT_REQUIRED"),
+		JWTSecret: viper.GetString("JWT_SECRET"),
+		AccessTokenTTLMinutes: viper.GetInt("ACCESS_TOKEN_TTL_MINUTES"),
+		RefreshTokenTTLHours: viper.GetInt("REFRESH_TOKEN_TTL_HOURS"),
+		AuthAdminUsername: viper.GetString("AUTH_ADMIN_USERNAME"),
+		AuthAdminPassword: viper.GetString("AUTH_ADMIN_PASSWORD"),
+	}
+	if configEnv.PaymentIntentTTLMinutes <= 0 {
+		configEnv.PaymentIntentTTLMinutes = 15
+	}
+	if configEnv.IdempotencyRetentionHours <= 0 {
+		configEnv.IdempotencyRetentionHours = 24
+	}
+	if configEnv.CartTTLHours <= 0 {
+		configEnv.CartTTLHours = 12
+	}
+	if configEnv.InvoiceOutletCode == "" {
+		configEnv.InvoiceOutletCode = "OUTLET"
+	}
+	if configEnv.InvoiceResetPeriod == "" {
+		configEnv.InvoiceResetPeriod = repositories.InvoiceResetMonthly
+	}
+	if configEnv.ReceiptShopName == "" {
+		configEnv.ReceiptShopName = "Kasir"
+	}
+	if configEnv.ReceiptFooter == "" {
+		configEnv.ReceiptFooter = "Terima kasih atas kunjungan Anda"
+	}
+	if configEnv.LoyaltyEarnRupiahPerPoint <= 0 {
+		configEnv.LoyaltyEarnRupiahPerPoint = 10000
+	}
+	if configEnv.LoyaltyPointValue <= 0 {
+		configEnv.LoyaltyPointValue = 100
+	}
+	if configEnv.LoyaltyPointExpiryDays <= 0 {
+		configEnv.LoyaltyPointExpiryDays = 365
+	}
+	if configEnv.AccessTokenTTLMinutes <= 0 {
+		configEnv.AccessTokenTTLMinutes = 15
+	}
+	if configEnv.RefreshTokenTTLHours <= 0 {
+		configEnv.RefreshTokenTTLHours = 24 * 7
+	}
+	if !repositories.ValidInvoiceResetPeriod(configEnv.InvoiceResetPeriod) {
+		log.Fatalf("Invalid INVOICE_RESET_PERIOD %q, use daily, monthly, yearly or never", configEnv.InvoiceResetPeriod)
+	}
+	if configEnv.PaymentWebhookSecret == "" {
+		if !configEnv.PaymentSandbox {
+			log.Fatal("PAYMENT_WEBHOOK_SECRET is not set, set it or enable PAYMENT_SANDBOX for local development")
+		}
+		log.Println("PAYMENT_WEBHOOK_SECRET is not set, using development secret for the sandbox gateway")
+		configEnv.PaymentWebhookSecret = "kasir-dev-secret"
+	}
+	if configEnv.JWTSecret == "" {
+		log.Fatal("JWT_SECRET is not set, refusing to start without a token signing secret")
 	}
 
 	// Initialize database
@@ -43,33 +136,194 @@ func main() {
 	}
 	defer db.Close()
 
+	userRepo := repositories.NewUserRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	authService := services.NewAuthService(userRepo, roleRepo, services.TokenConfig{
+		Secret:     []byte(configEnv.JWTSecret),
+		AccessTTL:  time.Duration(configEnv.AccessTokenTTLMinutes) * time.Minute,
+		RefreshTTL: time.Duration(configEnv.RefreshTokenTTLHours) * time.Hour,
+	})
+	roleService := services.NewRoleService(roleRepo, userRepo)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	authHandler := handlers.NewAuthHandler(authService, roleHandler)
+	auth := handlers.NewAuthMiddleware(authService)
+
+	// akun pertama dibuat dari AUTH_ADMIN_USERNAME / AUTH_ADMIN_PASSWORD bila tabel users masih kosong
+	created, err := authService.EnsureInitialUser(configEnv.AuthAdminUsername, configEnv.AuthAdminPassword)
+	if err != nil {
+		log.Fatalf("Failed to create initial user: %v", err)
+	}
+	if created {
+		log.Printf("Created initial user %q", configEnv.AuthAdminUsername)
+	}
+
 	productRepo := repositories.NewProductRepository(db)
 	productService := services.NewProductService(productRepo)
-	productHandler := handlers.NewProductHandler(productService)
+	stockRepo := repositories.NewStockRepository(db)
+	stockService := services.NewStockService(stockRepo)
+	productHandler := handlers.NewProductHandler(productService, stockService)
+	stockHandler := handlers.NewStockHandler(stockService)
+	opnameRepo := repositories.NewOpnameRepository(db)
+	opnameService := services.NewOpnameService(opnameRepo)
+	opnameHandler := handlers.NewOpnameHandler(opnameService)
+
+	supplierRepo := repositories.NewSupplierRepository(db)
+	purchaseOrderRepo := repositories.NewPurchaseOrderRepository(db)
+	supplierService := services.NewSupplierService(supplierRepo, purchaseOrderRepo)
+	goodsReceiptRepo := repositories.NewGoodsReceiptRepository(db)
+	goodsReceiptService := services.NewGoodsReceiptService(goodsReceiptRepo, supplierRepo)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo, supplierRepo, goodsReceiptService)
+	supplierHandler := handlers.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(purchaseOrderService)
+	goodsReceiptHandler := handlers.NewGoodsReceiptHandler(goodsReceiptService)
 
 	categoryRepo := repositories.NewCategoryRepository(db)
 	categoryService := services.NewCategoryService(categoryRepo)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 
-	transactionRepo := repositories.NewTransactionRepository(db)
-	transactionService := services.NewTransactionService(transactionRepo)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	taxClassRepo := repositories.NewTaxClassRepository(db)
+	taxClassService := services.NewTaxClassService(taxClassRepo)
+	taxClassHandler := handlers.NewTaxClassHandler(taxClassService)
+
+	promotionRepo := repositories.NewPromotionRepository(db)
+	promotionService := services.NewPromotionService(promotionRepo)
+	promotionHandler := handlers.NewPromotionHandler(promotionService)
+
+	// fake gateway untuk testing lokal hanya dipasang saat PAYMENT_SANDBOX=true,
+	// tanpa gateway checkout QRIS / e-wallet ditolak
+	paymentRepo := repositories.NewPaymentRepository(db)
+	var paymentGateway services.PaymentGateway
+	if configEnv.PaymentSandbox {
+		paymentGateway = services.NewFakePaymentGateway(configEnv.PaymentWebhookSecret)
+	}
+	paymentService := services.NewPaymentService(paymentRepo, paymentGateway, time.Duration(configEnv.PaymentIntentTTLMinutes)*time.Minute)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+
+	customerRepo := repositories.NewCustomerRepository(db)
+
+	debtRepo := repositories.NewDebtRepository(db)
+	debtService := services.NewDebtService(debtRepo, customerRepo)
+	debtHandler := handlers.NewDebtHandler(debtService)
+
+	loyaltyRepo := repositories.NewLoyaltyRepository(db)
+	loyaltyService := services.NewLoyaltyService(loyaltyRepo, customerRepo, services.LoyaltyProgram{
+		EarnRupiahPerPoint: configEnv.LoyaltyEarnRupiahPerPoint,
+		PointValue:         configEnv.LoyaltyPointValue,
+		Validity:           time.Duration(configEnv.LoyaltyPointExpiryDays) * 24 * time.Hour,
+	})
+	loyaltyHandler := handlers.NewLoyaltyHandler(loyaltyService)
+
+	transactionRepo := repositories.NewTransactionRepository(db, repositories.InvoiceSequence{
+		OutletCode:  configEnv.InvoiceOutletCode,
+		ResetPeriod: configEnv.InvoiceResetPeriod,
+	})
+	refundRepo := repositories.NewRefundRepository(db)
+	transactionService := services.NewTransactionService(transactionRepo, refundRepo, promotionRepo, customerRepo, debtRepo, paymentService, loyaltyService, configEnv.ShiftRequired)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, time.Duration(configEnv.IdempotencyRetentionHours)*time.Hour)
+
+	receiptService := services.NewReceiptService(transactionService, models.ShopProfile{
+		Name:    configEnv.ReceiptShopName,
+		Address: configEnv.ReceiptShopAddress,
+		Phone:   configEnv.ReceiptShopPhone,
+		Footer:  configEnv.ReceiptFooter,
+	})
+
+	// default pessimistic (SELECT ... FOR UPDATE), set CHECKOUT_LOCK_MODE=optimistic untuk mode retry
+	transactionHandler := handlers.NewTransactionHandler(transactionService, idempotencyService, receiptService, configEnv.CheckoutLockMode != "optimistic")
+
+	customerService := services.NewCustomerService(customerRepo, transactionService)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+
+	shiftRepo := repositories.NewShiftRepository(db)
+	shiftService := services.NewShiftService(shiftRepo)
+	shiftHandler := handlers.NewShiftHandler(shiftService)
+
+	cartRepo := repositories.NewCartRepository(db)
+	cartService := services.NewCartService(cartRepo, transactionService, time.Duration(configEnv.CartTTLHours)*time.Hour)
+	cartHandler := handlers.NewCartHandler(cartService, configEnv.CheckoutLockMode != "optimistic")
 
 	reportRepo := repositories.NewReportRepository(db)
 	reportService := services.NewReportService(reportRepo)
 	reportHandler := handlers.NewReportHandler(reportService)
 
-	// Setup routes
-	http.HandleFunc("/api/product", productHandler.HandleProducts)
-	http.HandleFunc("/api/product/", productHandler.HandleProductByID)
+	// Setup routes, semua endpoint selain login / refresh, callback payment gateway dan health wajib login.
+	// Setiap route mendeklarasikan permission yang dibutuhkan, lihat tabel permissions / role_permissions.
+	catalogAccess := handlers.Permissions{http.MethodGet: models.PermissionCatalogRead, "*": models.PermissionCatalogWrite}
+	customerAccess := handlers.Permissions{http.MethodGet: models.PermissionCustomerRead, "*": models.PermissionCustomerWrite}
+
+	http.HandleFunc("/api/auth/login", authHandler.HandleLogin)                        // POST
+	http.HandleFunc("/api/auth/refresh", authHandler.HandleRefresh)                    // POST
+	http.HandleFunc("/api/auth/logout", auth.Authenticated(authHandler.HandleLogout)) // POST
+	http.HandleFunc("/api/auth/me", auth.Authenticated(authHandler.HandleMe))         // GET
+
+	http.HandleFunc("/api/users", auth.Require(handlers.Any(models.PermissionUserManage), authHandler.HandleUsers))     // GET, POST
+	http.HandleFunc("/api/users/", auth.Require(handlers.Any(models.PermissionUserManage), authHandler.HandleUserByID)) // GET/PUT /{id}, GET/PUT /{id}/roles
+	http.HandleFunc("/api/roles", auth.Require(handlers.Any(models.PermissionUserManage), roleHandler.HandleRoles))     // GET, POST
+	http.HandleFunc("/api/roles/", auth.Require(handlers.Any(models.PermissionUserManage), roleHandler.HandleRoleByID)) // GET/PUT /{id}
+	http.HandleFunc("/api/permissions", auth.Require(handlers.Any(models.PermissionUserManage), roleHandler.HandlePermissions)) // GET
+
+	http.HandleFunc("/api/product", auth.Require(catalogAccess, productHandler.HandleProducts))
+	http.HandleFunc("/api/product/", auth.Require(catalogAccess, productHandler.HandleProductByID)) // GET/PUT/DELETE /{id}, GET /{id}/movements
 	
-	http.HandleFunc("/api/category", categoryHandler.HandleCategories)
-	http.HandleFunc("/api/category/", categoryHandler.HandleCategoryByID)
+	http.HandleFunc("/api/stock/adjustments", auth.Require(handlers.Any(models.PermissionStockAdjust), stockHandler.HandleAdjustments))      // GET ?start_date=&end_date=&page=&limit=, POST
+	http.HandleFunc("/api/stock/adjustments/", auth.Require(handlers.Any(models.PermissionStockAdjust), stockHandler.HandleAdjustmentByID)) // GET /{id}
+	http.HandleFunc("/api/stock/opnames", auth.Require(handlers.Permissions{http.MethodGet: models.PermissionStockCount, "*": models.PermissionStockAdjust},
+		opnameHandler.HandleOpnames)) // GET ?status=, POST (buka sesi + snapshot)
+	// approve mengubah stok, approve dan cancel butuh permission penyesuaian stok
+	opnameAccess := handlers.Permissions{"*": models.PermissionStockCount,
+		http.MethodPost + " /approve": models.PermissionStockAdjust, http.MethodPost + " /cancel": models.PermissionStockAdjust}
+	http.HandleFunc("/api/stock/opnames/", auth.Require(opnameAccess, opnameHandler.HandleOpnameByID)) // GET /{id}, POST /{id}/counts, POST /{id}/approve, POST /{id}/cancel
+
+	purchaseAccess := handlers.Permissions{http.MethodGet: models.PermissionPurchaseRead, "*": models.PermissionPurchaseManage}
+	http.HandleFunc("/api/suppliers", auth.Require(purchaseAccess, supplierHandler.HandleSuppliers))                     // GET ?search=, POST
+	http.HandleFunc("/api/suppliers/", auth.Require(purchaseAccess, supplierHandler.HandleSupplierByID))                 // GET/PUT /{id}, GET /{id}/purchase-orders?status=
+	http.HandleFunc("/api/purchase-orders", auth.Require(purchaseAccess, purchaseOrderHandler.HandlePurchaseOrders))     // GET ?status=&supplier_id=, POST
+	http.HandleFunc("/api/purchase-orders/", auth.Require(purchaseAccess, purchaseOrderHandler.HandlePurchaseOrderByID)) // GET/PUT /{id}, POST /{id}/send, POST /{id}/receive, POST /{id}/cancel
+	http.HandleFunc("/api/goods-receipts", auth.Require(purchaseAccess, goodsReceiptHandler.HandleGoodsReceipts))        // GET ?start_date=&end_date=&supplier_id=&supplier=, POST
+	http.HandleFunc("/api/goods-receipts/", auth.Require(purchaseAccess, goodsReceiptHandler.HandleGoodsReceiptByID))    // GET /{id}, POST /{id}/reverse
+
+	http.HandleFunc("/api/category", auth.Require(catalogAccess, categoryHandler.HandleCategories))
+	http.HandleFunc("/api/category/", auth.Require(catalogAccess, categoryHandler.HandleCategoryByID))
 
-	http.HandleFunc("/api/checkout", transactionHandler.HandleCheckout) // POST
+	http.HandleFunc("/api/tax-classes", auth.Require(catalogAccess, taxClassHandler.HandleTaxClasses))
+	http.HandleFunc("/api/tax-classes/", auth.Require(catalogAccess, taxClassHandler.HandleTaxClassByID))
+
+	http.HandleFunc("/api/promotions", auth.Require(catalogAccess, promotionHandler.HandlePromotions))
+	http.HandleFunc("/api/promotions/", auth.Require(catalogAccess, promotionHandler.HandlePromotionByID))
+
+	http.HandleFunc("/api/checkout", auth.Require(handlers.Any(models.PermissionCheckout), transactionHandler.HandleCheckout)) // POST
+	http.HandleFunc("/api/transactions", auth.Require(handlers.Any(models.PermissionTransactionRead), transactionHandler.HandleTransactions)) // GET with query params
+	http.HandleFunc("/api/transactions/", auth.Require(handlers.Permissions{http.MethodGet: models.PermissionTransactionRead, "*": models.PermissionTransactionRefund},
+		transactionHandler.HandleTransactionByID)) // GET, POST /{id}/void, POST /{id}/refund, GET /{id}/refunds, GET /{id}/receipt, GET /invoice/{invoice_number}
+
+	http.HandleFunc("/api/shifts", auth.Require(handlers.Any(models.PermissionShiftOperate), shiftHandler.HandleShifts))      // GET, POST (buka shift)
+	http.HandleFunc("/api/shifts/", auth.Require(handlers.Any(models.PermissionShiftOperate), shiftHandler.HandleShiftByID)) // GET /current, GET /{id}, POST /{id}/cash-movements, POST /{id}/close
+
+	http.HandleFunc("/api/customers", auth.Require(customerAccess, customerHandler.HandleCustomers))      // GET ?search=, POST
+	http.HandleFunc("/api/customers/", auth.Require(customerAccess, customerHandler.HandleCustomerByID)) // GET/PUT/DELETE /{id}, GET /{id}/transactions, GET /phone/{phone}
+
+	http.HandleFunc("/api/debtors", auth.Require(handlers.Permissions{http.MethodGet: models.PermissionDebtRead, "*": models.PermissionDebtManage},
+		debtHandler.HandleDebtors)) // GET ?outstanding=true, POST
+	http.HandleFunc("/api/debtors/", auth.Require(handlers.Permissions{http.MethodGet: models.PermissionDebtRead, http.MethodPost: models.PermissionDebtRepay, "*": models.PermissionDebtManage},
+		debtHandler.HandleDebtorByID)) // GET /aging, GET/PUT /{id}, GET /{id}/receivables, GET/POST /{id}/repayments
+
+	http.HandleFunc("/api/loyalty/", auth.Require(handlers.Any(models.PermissionCustomerRead), loyaltyHandler.HandleLoyalty)) // GET /{phone}, GET /{phone}/history
+
+	http.HandleFunc("/api/carts", auth.Require(handlers.Any(models.PermissionCheckout), cartHandler.HandleCarts))      // GET, POST
+	http.HandleFunc("/api/carts/", auth.Require(handlers.Any(models.PermissionCheckout), cartHandler.HandleCartByID)) // GET /{id}, /{id}/items, /{id}/hold, /{id}/resume, /{id}/checkout
 	
-	http.HandleFunc("/api/report/hari-ini", reportHandler.HandleDailyReport) // GET
-	http.HandleFunc("/api/report", reportHandler.HandleReport)                // GET with query params
+	http.HandleFunc("/api/payments/webhook", paymentHandler.HandleWebhook) // POST, callback payment gateway
+	if configEnv.PaymentSandbox {
+		http.HandleFunc("/api/payments/fake/pay", auth.Require(handlers.Any(models.PermissionCheckout), paymentHandler.HandleFakePay)) // POST, simulasi callback fake gateway
+	}
+	
+	http.HandleFunc("/api/report/hari-ini", auth.Require(handlers.Any(models.PermissionReportRead), reportHandler.HandleDailyReport)) // GET
+	http.HandleFunc("/api/report", auth.Require(handlers.Any(models.PermissionReportRead), reportHandler.HandleReport))                // GET with query params
+	http.HandleFunc("/api/report/x", auth.Require(handlers.Any(models.PermissionReportRead), reportHandler.HandleXReport))             // GET ?date=
+	http.HandleFunc("/api/report/z", auth.Require(handlers.Permissions{http.MethodGet: models.PermissionReportRead, "*": models.PermissionReportClose},
+		reportHandler.HandleZReports)) // GET, POST ?date= (tutup hari)
+	http.HandleFunc("/api/report/z/", auth.Require(handlers.Any(models.PermissionReportRead), reportHandler.HandleZReportByDate)) // GET /{date}
 
 	// localhost:8080/health
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -80,6 +334,86 @@ func main() {
 		})
 	})
 
+	// lepas reservasi stok transaksi QRIS / e-wallet yang tidak dibayar sampai batas waktu
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := paymentService.ExpireDue()
+			if err != nil {
+				log.Println("Failed to expire payment intents:", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Expired %d payment intents", expired)
+			}
+		}
+	}()
+
+	// bersihkan Idempotency-Key yang melewati masa retensi
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := idempotencyService.Cleanup()
+			if err != nil {
+				log.Println("Failed to clean up idempotency keys:", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d expired idempotency keys", deleted)
+			}
+		}
+	}()
+
+	// hapus sesi login yang sudah kedaluwarsa atau logout
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := authService.CleanupSessions()
+			if err != nil {
+				log.Println("Failed to clean up auth sessions:", err)
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d expired auth sessions", deleted)
+			}
+		}
+	}()
+
+	// kedaluwarsakan keranjang open / held yang ditinggalkan
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := cartService.ExpireAbandoned()
+			if err != nil {
+				log.Println("Failed to expire carts:", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Expired %d abandoned carts", expired)
+			}
+		}
+	}()
+
+	// hanguskan poin member yang melewati masa berlaku
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			expired, err := loyaltyService.ExpireDue()
+			if err != nil {
+				log.Println("Failed to expire loyalty points:", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Expired loyalty points of %d members", expired)
+			}
+		}
+	}()
+
 	// Start server
 	fmt.Println("Server running at localhost:" + configEnv.Port)
 
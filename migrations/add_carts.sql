@@ -0,0 +1,21 @@
+-- Migration untuk keranjang (open cart) yang bisa di-hold dan dilanjutkan
+
+CREATE TABLE carts (
+    id SERIAL PRIMARY KEY,
+    label VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'held', 'finalizing', 'finalized', 'expired')),
+    transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE cart_items (
+    id SERIAL PRIMARY KEY,
+    cart_id INTEGER NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    UNIQUE (cart_id, product_id)
+);
+
+CREATE INDEX idx_carts_status_expires_at ON carts(status, expires_at);
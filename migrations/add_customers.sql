@@ -0,0 +1,15 @@
+-- Migration untuk data pelanggan dan relasi pelanggan ke transaksi
+
+CREATE TABLE customers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    -- nomor telepon disimpan dalam format ternormalisasi (08xxx) agar pencarian di kasir konsisten
+    phone VARCHAR(20) UNIQUE,
+    email VARCHAR(255),
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE transactions ADD COLUMN customer_id INTEGER REFERENCES customers(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_transactions_customer_id ON transactions(customer_id);
@@ -0,0 +1,46 @@
+-- Migration untuk kasbon: debitur, piutang per transaksi dan pembayaran cicilan
+
+CREATE TABLE debtors (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL UNIQUE REFERENCES customers(id) ON DELETE RESTRICT,
+    credit_limit INTEGER NOT NULL CHECK (credit_limit >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Sisa piutang = amount - paid_amount - credited_amount, credited_amount berasal dari refund / void
+CREATE TABLE receivables (
+    id SERIAL PRIMARY KEY,
+    debtor_id INTEGER NOT NULL REFERENCES debtors(id) ON DELETE RESTRICT,
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id) ON DELETE RESTRICT,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    paid_amount INTEGER NOT NULL DEFAULT 0,
+    credited_amount INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'settled')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (paid_amount + credited_amount <= amount)
+);
+
+CREATE INDEX idx_receivables_debtor_status ON receivables(debtor_id, status);
+
+CREATE TABLE debt_repayments (
+    id SERIAL PRIMARY KEY,
+    debtor_id INTEGER NOT NULL REFERENCES debtors(id) ON DELETE RESTRICT,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    method VARCHAR(20) NOT NULL CHECK (method IN ('cash', 'debit', 'transfer')),
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Cicilan dialokasikan ke piutang paling lama lebih dulu
+CREATE TABLE debt_repayment_allocations (
+    id SERIAL PRIMARY KEY,
+    repayment_id INTEGER NOT NULL REFERENCES debt_repayments(id) ON DELETE CASCADE,
+    receivable_id INTEGER NOT NULL REFERENCES receivables(id) ON DELETE RESTRICT,
+    amount INTEGER NOT NULL CHECK (amount > 0)
+);
+
+CREATE INDEX idx_debt_repayments_debtor_id ON debt_repayments(debtor_id);
+
+-- Bagian refund yang memotong sisa kasbon, bukan uang yang dikembalikan
+ALTER TABLE refunds ADD COLUMN credited_amount INTEGER NOT NULL DEFAULT 0;
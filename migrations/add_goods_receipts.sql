@@ -0,0 +1,48 @@
+-- Migration untuk penerimaan barang (goods receipt) dan harga pokok rata-rata bergerak
+
+-- harga pokok per unit, diperbarui rata-rata bergerak setiap penerimaan barang di-posting / dibatalkan
+ALTER TABLE products ADD COLUMN cost_price INTEGER NOT NULL DEFAULT 0 CHECK (cost_price >= 0);
+
+-- posted -> reversed, dokumen tidak pernah dihapus
+CREATE TABLE goods_receipts (
+    id SERIAL PRIMARY KEY,
+    supplier_id INTEGER REFERENCES suppliers(id),
+    supplier_name VARCHAR(255) NOT NULL,
+    invoice_ref VARCHAR(100),
+    purchase_order_id INTEGER REFERENCES purchase_orders(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'posted' CHECK (status IN ('posted', 'reversed')),
+    note TEXT,
+    total_amount INTEGER NOT NULL DEFAULT 0,
+    user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reversed_by INTEGER REFERENCES users(id),
+    reversed_at TIMESTAMP,
+    reverse_reason TEXT
+);
+
+CREATE INDEX idx_goods_receipts_created_at ON goods_receipts(created_at);
+CREATE INDEX idx_goods_receipts_supplier_id ON goods_receipts(supplier_id);
+CREATE INDEX idx_goods_receipts_purchase_order_id ON goods_receipts(purchase_order_id);
+
+CREATE TABLE goods_receipt_items (
+    id SERIAL PRIMARY KEY,
+    receipt_id INTEGER NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    -- harga beli aktual per unit sesuai faktur
+    unit_cost INTEGER NOT NULL CHECK (unit_cost >= 0),
+    -- harga pokok produk sebelum dan sesudah baris ini di-posting
+    cost_before INTEGER NOT NULL,
+    cost_after INTEGER NOT NULL,
+    stock_movement_id INTEGER NOT NULL REFERENCES stock_movements(id),
+    -- movement pembatalan, terisi saat dokumen di-reverse
+    reversal_movement_id INTEGER REFERENCES stock_movements(id)
+);
+
+CREATE INDEX idx_goods_receipt_items_receipt_id ON goods_receipt_items(receipt_id);
+
+UPDATE permissions SET description = 'Kelola supplier, purchase order dan penerimaan barang (posting / pembatalan)'
+WHERE code = 'purchase.manage';
+UPDATE permissions SET description = 'Lihat supplier, purchase order dan penerimaan barang'
+WHERE code = 'purchase.read';
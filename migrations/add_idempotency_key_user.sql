@@ -0,0 +1,7 @@
+-- Migration untuk Idempotency-Key per pengguna
+
+-- Key yang sama dari pengguna berbeda adalah request yang berbeda. Key lama tanpa pengguna tetap bisa diputar ulang
+-- sampai dibersihkan masa retensi.
+ALTER TABLE idempotency_keys ADD COLUMN user_id INTEGER REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+CREATE UNIQUE INDEX idx_idempotency_keys_user_key ON idempotency_keys ((COALESCE(user_id, 0)), key);
@@ -0,0 +1,29 @@
+-- Migration untuk nomor invoice berurutan tanpa loncat, misalnya INV/OUTLET/2026/10/000123
+
+CREATE TABLE invoice_counters (
+    outlet_code VARCHAR(20) NOT NULL,
+    -- format period_key mengikuti INVOICE_RESET_PERIOD: 2026-10-18 (daily), 2026-10 (monthly), 2026 (yearly), all (never)
+    period_key VARCHAR(10) NOT NULL,
+    last_number INTEGER NOT NULL,
+    PRIMARY KEY (outlet_code, period_key)
+);
+
+ALTER TABLE transactions ADD COLUMN invoice_number VARCHAR(50);
+
+-- Transaksi lama diberi nomor per bulan dengan outlet code default OUTLET dan reset bulanan,
+-- sesuaikan bila INVOICE_OUTLET_CODE / INVOICE_RESET_PERIOD berbeda
+UPDATE transactions t
+SET invoice_number = 'INV/OUTLET/' || TO_CHAR(n.created_at, 'YYYY/MM') || '/' || LPAD(n.seq::TEXT, 6, '0')
+FROM (
+    SELECT id, created_at, ROW_NUMBER() OVER (PARTITION BY DATE_TRUNC('month', created_at) ORDER BY created_at, id) AS seq
+    FROM transactions
+) n
+WHERE t.id = n.id;
+
+INSERT INTO invoice_counters (outlet_code, period_key, last_number)
+SELECT 'OUTLET', TO_CHAR(created_at, 'YYYY-MM'), COUNT(*)
+FROM transactions
+GROUP BY TO_CHAR(created_at, 'YYYY-MM');
+
+ALTER TABLE transactions ALTER COLUMN invoice_number SET NOT NULL;
+ALTER TABLE transactions ADD CONSTRAINT transactions_invoice_number_key UNIQUE (invoice_number);
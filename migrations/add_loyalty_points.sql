@@ -0,0 +1,30 @@
+-- Migration untuk program poin loyalti member (pelanggan dengan nomor telepon)
+
+ALTER TABLE transactions
+ADD COLUMN points_earned INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN points_redeemed INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN points_expire_at TIMESTAMP;
+
+-- Poin bisa dipakai sebagai tender pembayaran
+ALTER TABLE payments DROP CONSTRAINT payments_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_method_check
+    CHECK (method IN ('cash', 'debit', 'qris', 'e_wallet', 'transfer', 'points'));
+
+-- Ledger poin: points bertanda (+ masuk, - keluar), balance_after adalah saldo setelah entri.
+-- expires_at diisi untuk earn dan earn_reversal (mengikuti batch earn asalnya).
+-- Ledger tidak ikut terhapus, member yang memiliki riwayat poin tidak bisa dihapus.
+CREATE TABLE loyalty_ledger (
+    id SERIAL PRIMARY KEY,
+    customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE RESTRICT,
+    transaction_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+    refund_id INTEGER REFERENCES refunds(id) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('earn', 'redeem', 'earn_reversal', 'redeem_reversal', 'expire')),
+    points INTEGER NOT NULL,
+    balance_after INTEGER NOT NULL,
+    expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_loyalty_ledger_customer_id ON loyalty_ledger(customer_id, id);
+CREATE INDEX idx_loyalty_ledger_transaction_id ON loyalty_ledger(transaction_id);
+CREATE INDEX idx_loyalty_ledger_earn_expires_at ON loyalty_ledger(expires_at) WHERE type = 'earn';
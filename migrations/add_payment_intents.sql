@@ -0,0 +1,22 @@
+-- Migration untuk pembayaran non-tunai asinkron (QRIS / e-wallet) lewat payment gateway
+
+-- Status pembayaran per tender: paid, pending, expired
+ALTER TABLE payments
+ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'paid';
+
+CREATE TABLE payment_intents (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    gateway VARCHAR(50) NOT NULL,
+    external_id VARCHAR(255) UNIQUE,
+    qr_string TEXT,
+    amount INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'expired', 'failed')),
+    expires_at TIMESTAMP NOT NULL,
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payment_intents_pending ON payment_intents(status, expires_at);
+CREATE INDEX idx_payment_intents_transaction_id ON payment_intents(transaction_id);
@@ -0,0 +1,24 @@
+-- Migration untuk pencatatan pembayaran (multi tender) per transaksi
+
+CREATE TABLE payments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('cash', 'debit', 'qris', 'e_wallet', 'transfer')),
+    -- amount adalah nominal yang masuk ke penjualan, tendered_amount uang yang diserahkan pelanggan
+    amount INTEGER NOT NULL,
+    tendered_amount INTEGER NOT NULL,
+    reference VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payments_transaction_id ON payments(transaction_id);
+
+ALTER TABLE transactions
+ADD COLUMN paid_amount INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN change_amount INTEGER NOT NULL DEFAULT 0;
+
+-- Transaksi lama dianggap dibayar tunai pas
+UPDATE transactions SET paid_amount = total_amount;
+
+INSERT INTO payments (transaction_id, method, amount, tendered_amount, created_at)
+SELECT id, 'cash', total_amount, total_amount, created_at FROM transactions;
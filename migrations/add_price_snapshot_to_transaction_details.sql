@@ -0,0 +1,14 @@
+-- Migration untuk menyimpan snapshot harga satuan dan nama produk per baris transaksi
+
+ALTER TABLE transaction_details
+ADD COLUMN unit_price INTEGER,
+ADD COLUMN product_name VARCHAR(255);
+
+-- Isi data lama: harga satuan dihitung dari subtotal, nama diambil dari produk saat ini
+UPDATE transaction_details td
+SET unit_price = COALESCE(td.subtotal / NULLIF(td.quantity, 0), 0),
+    product_name = COALESCE((SELECT p.name FROM products p WHERE p.id = td.product_id), '');
+
+ALTER TABLE transaction_details
+ALTER COLUMN unit_price SET NOT NULL,
+ALTER COLUMN product_name SET NOT NULL;
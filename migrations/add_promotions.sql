@@ -0,0 +1,39 @@
+-- Migration untuk promosi dan diskon checkout
+
+CREATE TABLE promotions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('percentage', 'fixed', 'buy_x_get_y')),
+    value INTEGER NOT NULL DEFAULT 0,
+    product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+    category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+    buy_qty INTEGER NOT NULL DEFAULT 0,
+    get_qty INTEGER NOT NULL DEFAULT 0,
+    start_at TIMESTAMP,
+    end_at TIMESTAMP,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Total sebelum diskon dan total diskon per transaksi, total_amount menjadi nilai bersih
+ALTER TABLE transactions
+ADD COLUMN subtotal_amount INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN discount_amount INTEGER NOT NULL DEFAULT 0;
+
+UPDATE transactions SET subtotal_amount = total_amount;
+
+ALTER TABLE transaction_details
+ADD COLUMN discount_amount INTEGER NOT NULL DEFAULT 0;
+
+-- Diskon yang diterapkan per baris transaksi, nama promo disimpan sebagai snapshot
+CREATE TABLE transaction_discounts (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    transaction_detail_id INTEGER NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    promotion_id INTEGER REFERENCES promotions(id) ON DELETE SET NULL,
+    promotion_name VARCHAR(255) NOT NULL,
+    amount INTEGER NOT NULL
+);
+
+CREATE INDEX idx_transaction_discounts_transaction_id ON transaction_discounts(transaction_id);
+CREATE INDEX idx_promotions_active ON promotions(active, start_at, end_at);
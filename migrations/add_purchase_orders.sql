@@ -0,0 +1,55 @@
+-- Migration untuk supplier dan purchase order
+
+CREATE TABLE suppliers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255),
+    phone VARCHAR(30),
+    email VARCHAR(255),
+    address TEXT,
+    -- tempo pembayaran dalam hari, 0 berarti tunai
+    payment_term_days INTEGER NOT NULL DEFAULT 0 CHECK (payment_term_days >= 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_suppliers_name ON suppliers(LOWER(name));
+
+-- draft -> sent -> partially_received -> received, cancelled dari draft / sent / partially_received
+CREATE TABLE purchase_orders (
+    id SERIAL PRIMARY KEY,
+    supplier_id INTEGER NOT NULL REFERENCES suppliers(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'sent', 'partially_received', 'received', 'cancelled')),
+    expected_date DATE,
+    note TEXT,
+    total_amount INTEGER NOT NULL DEFAULT 0,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+    received_at TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    cancel_reason TEXT
+);
+
+CREATE INDEX idx_purchase_orders_supplier_status ON purchase_orders(supplier_id, status);
+
+CREATE TABLE purchase_order_items (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    -- perkiraan harga beli per unit
+    unit_cost INTEGER NOT NULL CHECK (unit_cost >= 0),
+    received_qty INTEGER NOT NULL DEFAULT 0 CHECK (received_qty >= 0 AND received_qty <= quantity),
+    UNIQUE (purchase_order_id, product_id)
+);
+
+INSERT INTO permissions (code, description) VALUES
+    ('purchase.read', 'Lihat supplier dan purchase order'),
+    ('purchase.manage', 'Kelola supplier, buat, kirim, terima dan batalkan purchase order');
+
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT r.id, p.code FROM roles r CROSS JOIN permissions p
+WHERE r.name IN ('owner', 'manager') AND p.code IN ('purchase.read', 'purchase.manage');
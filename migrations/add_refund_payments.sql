@@ -0,0 +1,17 @@
+-- Migration untuk pembagian nominal refund ke tender pembayaran asal
+
+-- Bagian refund yang tidak memotong kasbon (total_amount - credited_amount) dibagi ke tender transaksi asal.
+-- Bagian tender poin dikembalikan sebagai poin (points), tender lain sebagai uang dengan metode yang sama.
+CREATE TABLE refund_payments (
+    id SERIAL PRIMARY KEY,
+    refund_id INTEGER NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    points INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_refund_payments_refund_id ON refund_payments(refund_id);
+
+-- refund lama dikembalikan seluruhnya sebagai uang tunai
+INSERT INTO refund_payments (refund_id, method, amount)
+SELECT id, 'cash', total_amount - credited_amount FROM refunds WHERE total_amount - credited_amount > 0;
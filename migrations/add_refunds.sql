@@ -0,0 +1,28 @@
+-- Migration untuk void dan refund transaksi
+
+-- Status transaksi: completed, partially_refunded, refunded, voided
+ALTER TABLE transactions
+ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'completed';
+
+-- Dokumen pembalik (void / refund) yang terhubung ke transaksi asal
+CREATE TABLE refunds (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+    type VARCHAR(10) NOT NULL CHECK (type IN ('void', 'refund')),
+    reason TEXT NOT NULL,
+    total_amount INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Item yang dikembalikan per baris transaksi
+CREATE TABLE refund_details (
+    id SERIAL PRIMARY KEY,
+    refund_id INTEGER NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    transaction_detail_id INTEGER NOT NULL REFERENCES transaction_details(id),
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    amount INTEGER NOT NULL
+);
+
+CREATE INDEX idx_refunds_transaction_id ON refunds(transaction_id);
+CREATE INDEX idx_refund_details_transaction_detail_id ON refund_details(transaction_detail_id);
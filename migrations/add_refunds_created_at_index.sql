@@ -0,0 +1,3 @@
+-- Migration untuk laporan yang mencatat refund / void pada tanggal refund dibuat
+
+CREATE INDEX idx_refunds_created_at ON refunds(created_at);
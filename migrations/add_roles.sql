@@ -0,0 +1,63 @@
+-- Migration untuk role dan permission (RBAC)
+
+CREATE TABLE permissions (
+    code VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL
+);
+
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_code VARCHAR(50) NOT NULL REFERENCES permissions(code) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_code)
+);
+
+CREATE TABLE user_roles (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO permissions (code, description) VALUES
+    ('catalog.read', 'Lihat produk, kategori, kelas pajak dan promo'),
+    ('catalog.write', 'Tambah, ubah harga dan hapus produk, kategori, kelas pajak dan promo'),
+    ('checkout', 'Checkout dan kelola keranjang'),
+    ('transaction.read', 'Lihat riwayat transaksi dan cetak struk'),
+    ('transaction.refund', 'Void dan refund transaksi'),
+    ('shift.operate', 'Buka / tutup shift dan catat kas masuk / keluar'),
+    ('customer.read', 'Lihat pelanggan dan saldo poin'),
+    ('customer.write', 'Tambah, ubah dan hapus pelanggan'),
+    ('debt.read', 'Lihat debitur dan piutang kasbon'),
+    ('debt.repay', 'Terima cicilan kasbon'),
+    ('debt.manage', 'Daftarkan debitur dan ubah limit kasbon'),
+    ('report.read', 'Lihat laporan penjualan dan laporan X / Z'),
+    ('report.close', 'Tutup hari dengan laporan Z'),
+    ('user.manage', 'Kelola pengguna, role dan permission');
+
+INSERT INTO roles (name, description) VALUES
+    ('owner', 'Pemilik toko, seluruh akses'),
+    ('manager', 'Refund, laporan dan pengelolaan katalog'),
+    ('cashier', 'Checkout dan operasional kasir');
+
+-- owner selalu memiliki seluruh permission
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT r.id, p.code FROM roles r CROSS JOIN permissions p WHERE r.name = 'owner';
+
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT r.id, p.code FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'manager' AND p.code <> 'user.manage';
+
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT r.id, p.code FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'cashier' AND p.code IN ('catalog.read', 'checkout', 'transaction.read', 'shift.operate',
+    'customer.read', 'customer.write', 'debt.read', 'debt.repay');
+
+-- pengguna yang sudah ada sebelum RBAC dianggap owner agar tidak terkunci
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM users u CROSS JOIN roles r WHERE r.name = 'owner';
@@ -0,0 +1,38 @@
+-- Migration untuk shift kasir dan rekonsiliasi laci kas
+
+-- Hanya boleh ada satu shift open dalam satu waktu
+CREATE TABLE shifts (
+    id SERIAL PRIMARY KEY,
+    cashier_name VARCHAR(255) NOT NULL,
+    opening_float INTEGER NOT NULL CHECK (opening_float >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'closed')),
+    -- diisi saat shift ditutup, expected_cash dibekukan agar laporan shift tidak berubah
+    counted_cash INTEGER,
+    expected_cash INTEGER,
+    close_note TEXT,
+    opened_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_shifts_single_open ON shifts(status) WHERE status = 'open';
+
+-- Kas masuk / keluar di luar penjualan, misalnya kas kecil
+CREATE TABLE cash_movements (
+    id SERIAL PRIMARY KEY,
+    shift_id INTEGER NOT NULL REFERENCES shifts(id) ON DELETE CASCADE,
+    type VARCHAR(10) NOT NULL CHECK (type IN ('cash_in', 'cash_out')),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_cash_movements_shift_id ON cash_movements(shift_id);
+
+-- Dokumen yang menggerakkan uang di laci dicatat pada shift yang open saat dibuat
+ALTER TABLE transactions ADD COLUMN shift_id INTEGER REFERENCES shifts(id);
+ALTER TABLE refunds ADD COLUMN shift_id INTEGER REFERENCES shifts(id);
+ALTER TABLE debt_repayments ADD COLUMN shift_id INTEGER REFERENCES shifts(id);
+
+CREATE INDEX idx_transactions_shift_id ON transactions(shift_id);
+CREATE INDEX idx_refunds_shift_id ON refunds(shift_id);
+CREATE INDEX idx_debt_repayments_shift_id ON debt_repayments(shift_id);
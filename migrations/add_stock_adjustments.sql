@@ -0,0 +1,28 @@
+-- Migration untuk penyesuaian stok dengan kode alasan (barang rusak, kedaluwarsa, hilang, ditemukan, koreksi)
+
+CREATE TABLE stock_adjustments (
+    id SERIAL PRIMARY KEY,
+    note TEXT,
+    user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE stock_adjustment_items (
+    id SERIAL PRIMARY KEY,
+    adjustment_id INTEGER NOT NULL REFERENCES stock_adjustments(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity <> 0),
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('damaged', 'expired', 'lost', 'found', 'correction')),
+    -- id movement kartu stok yang dibuat baris ini
+    stock_movement_id INTEGER NOT NULL REFERENCES stock_movements(id)
+);
+
+CREATE INDEX idx_stock_adjustment_items_adjustment_id ON stock_adjustment_items(adjustment_id);
+CREATE INDEX idx_stock_adjustments_created_at ON stock_adjustments(created_at);
+
+INSERT INTO permissions (code, description) VALUES
+    ('stock.adjust', 'Catat penyesuaian stok (rusak, kedaluwarsa, hilang, ditemukan, koreksi)');
+
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT r.id, 'stock.adjust' FROM roles r WHERE r.name IN ('owner', 'manager');
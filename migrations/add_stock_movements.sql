@@ -0,0 +1,41 @@
+-- Migration untuk kartu stok: setiap perubahan stok dicatat sebagai movement yang tidak bisa diubah,
+-- products.stock menjadi saldo cache yang selalu sama dengan SUM(quantity) movement produk tersebut
+
+CREATE TABLE stock_movements (
+    id SERIAL PRIMARY KEY,
+    -- tanpa foreign key agar riwayat tetap ada walaupun produk dihapus
+    product_id INTEGER NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('sale', 'refund', 'adjustment', 'receipt', 'transfer', 'opname')),
+    quantity INTEGER NOT NULL CHECK (quantity <> 0),
+    balance_after INTEGER NOT NULL,
+    -- dokumen sumber, misalnya transaction / refund / product
+    reference_type VARCHAR(30) NOT NULL,
+    reference_id INTEGER,
+    note TEXT,
+    user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_stock_movements_product_id ON stock_movements(product_id, id);
+CREATE INDEX idx_stock_movements_reference ON stock_movements(reference_type, reference_id);
+
+-- Movement bersifat append-only, koreksi dicatat sebagai movement baru
+CREATE OR REPLACE FUNCTION stock_movements_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements tidak bisa diubah atau dihapus';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_stock_movements_immutable
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_immutable();
+
+-- Saldo awal dari stok yang sudah ada sebelum kartu stok dipakai
+INSERT INTO stock_movements (product_id, type, quantity, balance_after, reference_type, reference_id, note)
+SELECT id, 'adjustment', stock, stock, 'opening', id, 'saldo awal kartu stok'
+FROM products
+WHERE stock <> 0;
+
+-- Pengguna yang mencatat transaksi dan refund
+ALTER TABLE transactions ADD COLUMN user_id INTEGER REFERENCES users(id);
+ALTER TABLE refunds ADD COLUMN user_id INTEGER REFERENCES users(id);
@@ -0,0 +1,55 @@
+-- Migration untuk stock opname (hitung fisik stok)
+
+-- Hanya boleh ada satu sesi opname open dalam satu waktu
+CREATE TABLE stock_opnames (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'approved', 'cancelled')),
+    -- kategori yang dihitung, NULL berarti seluruh produk
+    category_ids INTEGER[],
+    note TEXT,
+    created_by INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    approved_by INTEGER REFERENCES users(id),
+    approved_at TIMESTAMP,
+    cancelled_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_stock_opnames_single_open ON stock_opnames(status) WHERE status = 'open';
+
+-- Snapshot stok sistem saat sesi dibuat. Kolom hasil diisi saat sesi di-approve agar laporan tidak berubah.
+CREATE TABLE stock_opname_items (
+    id SERIAL PRIMARY KEY,
+    opname_id INTEGER NOT NULL REFERENCES stock_opnames(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL,
+    product_name VARCHAR(255) NOT NULL,
+    category_id INTEGER,
+    snapshot_stock INTEGER NOT NULL,
+    counted_qty INTEGER,
+    expected_qty INTEGER,
+    variance INTEGER,
+    unit_price INTEGER,
+    stock_movement_id INTEGER REFERENCES stock_movements(id),
+    UNIQUE (opname_id, product_id)
+);
+
+-- Setiap input hitungan disimpan. Penghitung adalah putaran verifikasi, bukan zona: setiap hitungan mencakup
+-- seluruh stok produk dan hitungan paling akhir antar penghitung menjadi hasil produk (tidak dijumlahkan).
+-- system_stock adalah stok sistem saat hitungan dicatat (snapshot + movement sejak snapshot).
+CREATE TABLE stock_opname_counts (
+    id SERIAL PRIMARY KEY,
+    opname_id INTEGER NOT NULL REFERENCES stock_opnames(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL,
+    counter VARCHAR(100) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    system_stock INTEGER NOT NULL,
+    user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_stock_opname_counts_opname ON stock_opname_counts(opname_id, product_id, counter, id);
+
+INSERT INTO permissions (code, description) VALUES
+    ('stock.count', 'Lihat sesi stock opname dan input hitungan fisik');
+
+INSERT INTO role_permissions (role_id, permission_code)
+SELECT r.id, 'stock.count' FROM roles r WHERE r.name IN ('owner', 'manager', 'cashier');
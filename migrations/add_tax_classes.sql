@@ -0,0 +1,29 @@
+-- Migration untuk pajak (PPN) dengan kelas pajak per produk / kategori
+
+CREATE TABLE tax_classes (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    rate NUMERIC(5, 2) NOT NULL CHECK (rate >= 0),
+    price_includes_tax BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+INSERT INTO tax_classes (name, rate, price_includes_tax) VALUES ('PPN 11%', 11.00, TRUE);
+INSERT INTO tax_classes (name, rate, price_includes_tax) VALUES ('Bebas PPN', 0, TRUE);
+
+-- Kelas pajak produk mengalahkan kelas pajak kategori, tanpa keduanya produk tidak dikenai pajak
+ALTER TABLE categories
+ADD COLUMN tax_class_id INTEGER REFERENCES tax_classes(id) ON DELETE SET NULL;
+
+ALTER TABLE products
+ADD COLUMN tax_class_id INTEGER REFERENCES tax_classes(id) ON DELETE SET NULL;
+
+-- Snapshot pajak per baris transaksi
+ALTER TABLE transaction_details
+ADD COLUMN tax_class_id INTEGER,
+ADD COLUMN tax_class_name VARCHAR(100),
+ADD COLUMN tax_rate NUMERIC(5, 2) NOT NULL DEFAULT 0,
+ADD COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN tax_amount INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE transactions
+ADD COLUMN tax_amount INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- Migration untuk laporan Z (tutup hari)
+
+-- Satu laporan Z per tanggal bisnis, isi laporan dibekukan saat dibuat.
+-- Setelah tanggal disegel, checkout dan void / refund transaksi tanggal tersebut ditolak.
+CREATE TABLE z_reports (
+    id SERIAL PRIMARY KEY,
+    business_date DATE NOT NULL UNIQUE,
+    report JSONB NOT NULL,
+    generated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+const (
+	CartStatusOpen       = "open"
+	CartStatusHeld       = "held"
+	CartStatusFinalizing = "finalizing"
+	CartStatusFinalized  = "finalized"
+	CartStatusExpired    = "expired"
+)
+
+// Cart - keranjang yang disimpan di server, harga dan stok item selalu diambil dari data produk terkini
+type Cart struct {
+	ID            int        `json:"id"`
+	Label         *string    `json:"label"`
+	Status        string     `json:"status"`
+	TransactionID *int       `json:"transaction_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	Items         []CartItem `json:"items"`
+	Total         int        `json:"total"`
+}
+
+// CartItem - StockWarning terisi bila stok produk saat ini tidak mencukupi quantity di keranjang
+type CartItem struct {
+	ID           int    `json:"id"`
+	ProductID    int    `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	UnitPrice    int    `json:"unit_price"`
+	Quantity     int    `json:"quantity"`
+	Subtotal     int    `json:"subtotal"`
+	Stock        int    `json:"stock"`
+	StockWarning string `json:"stock_warning,omitempty"`
+}
+
+type CartRequest struct {
+	Label *string `json:"label"`
+}
+
+type CartItemRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// CartCheckoutRequest - item diambil dari keranjang, request hanya berisi pembayaran dan pelanggan / member / debitur
+type CartCheckoutRequest struct {
+	Payments    []PaymentInput `json:"payments"`
+	CustomerID  *int           `json:"customer_id"`
+	MemberPhone string         `json:"member_phone"`
+	DebtorID    *int           `json:"debtor_id"`
+	// UserID - pengguna yang login, diisi handler dan bukan dari body request
+	UserID *int `json:"-"`
+}
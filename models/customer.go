@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Customer struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Phone     *string   `json:"phone"`
+	Email     *string   `json:"email"`
+	Notes     *string   `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+}
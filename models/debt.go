@@ -0,0 +1,79 @@
+package models
+
+import "time"
+
+const (
+	ReceivableStatusOpen    = "open"
+	ReceivableStatusSettled = "settled"
+)
+
+// Debtor - pelanggan yang boleh kasbon sampai CreditLimit, Outstanding adalah total sisa piutangnya
+type Debtor struct {
+	ID           int       `json:"id"`
+	CustomerID   int       `json:"customer_id"`
+	CustomerName string    `json:"customer_name"`
+	Phone        *string   `json:"phone"`
+	CreditLimit  int       `json:"credit_limit"`
+	Outstanding  int       `json:"outstanding"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DebtorRequest - Active nil berarti tidak diubah (default aktif saat dibuat)
+type DebtorRequest struct {
+	CustomerID  int   `json:"customer_id"`
+	CreditLimit int   `json:"credit_limit"`
+	Active      *bool `json:"active"`
+}
+
+// Receivable - piutang dari satu transaksi kasbon
+type Receivable struct {
+	ID             int       `json:"id"`
+	DebtorID       int       `json:"debtor_id"`
+	TransactionID  int       `json:"transaction_id"`
+	InvoiceNumber  string    `json:"invoice_number,omitempty"`
+	Amount         int       `json:"amount"`
+	PaidAmount     int       `json:"paid_amount"`
+	CreditedAmount int       `json:"credited_amount"`
+	Outstanding    int       `json:"outstanding"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type DebtRepaymentRequest struct {
+	Amount int     `json:"amount"`
+	Method string  `json:"method"`
+	Note   *string `json:"note"`
+}
+
+// DebtRepayment - pembayaran cicilan kasbon beserta alokasinya ke piutang
+type DebtRepayment struct {
+	ID          int              `json:"id"`
+	DebtorID    int              `json:"debtor_id"`
+	Amount      int              `json:"amount"`
+	Method      string           `json:"method"`
+	Note        *string          `json:"note"`
+	ShiftID     *int             `json:"shift_id"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Allocations []DebtAllocation `json:"allocations"`
+}
+
+type DebtAllocation struct {
+	ReceivableID int `json:"receivable_id"`
+	Amount       int `json:"amount"`
+}
+
+// DebtAging - sisa piutang debitur dikelompokkan menurut umur piutang
+type DebtAging struct {
+	DebtorID     int    `json:"debtor_id"`
+	CustomerName string `json:"customer_name"`
+	Days0To30    int    `json:"days_0_30"`
+	Days31To60   int    `json:"days_31_60"`
+	Over60       int    `json:"over_60"`
+	Total        int    `json:"total"`
+}
+
+type DebtAgingReport struct {
+	Debtors []DebtAging `json:"debtors"`
+	Totals  DebtAging   `json:"totals"`
+}
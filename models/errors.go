@@ -0,0 +1,48 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StockShortage - item checkout yang stoknya tidak mencukupi
+type StockShortage struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Requested   int    `json:"requested"`
+	Available   int    `json:"available"`
+}
+
+// InsufficientStockError - checkout ditolak karena satu atau lebih item melebihi stok
+type InsufficientStockError struct {
+	Items []StockShortage `json:"items"`
+}
+
+func (e *InsufficientStockError) Error() string {
+	parts := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		parts = append(parts, fmt.Sprintf("%s (id %d): diminta %d, tersedia %d", item.ProductName, item.ProductID, item.Requested, item.Available))
+	}
+	return "stok tidak mencukupi: " + strings.Join(parts, "; ")
+}
+
+// ItemError - kesalahan validasi pada satu baris item checkout
+type ItemError struct {
+	Index     int    `json:"index"`
+	ProductID int    `json:"product_id"`
+	Field     string `json:"field"`
+	Reason    string `json:"reason"`
+}
+
+// ValidationError - request tidak valid, Items berisi daftar baris yang bermasalah
+type ValidationError struct {
+	Message string      `json:"error"`
+	Items   []ItemError `json:"items,omitempty"`
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Items) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%d item bermasalah)", e.Message, len(e.Items))
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+const (
+	GoodsReceiptStatusPosted   = "posted"
+	GoodsReceiptStatusReversed = "reversed"
+)
+
+// GoodsReceipt - dokumen penerimaan barang, langsung di-posting saat dibuat. SupplierID dan PurchaseOrderID
+// opsional, SupplierName selalu disimpan sesuai faktur. TotalAmount adalah jumlah quantity * unit_cost.
+type GoodsReceipt struct {
+	ID              int                `json:"id"`
+	SupplierID      *int               `json:"supplier_id"`
+	SupplierName    string             `json:"supplier_name"`
+	InvoiceRef      *string            `json:"invoice_ref"`
+	PurchaseOrderID *int               `json:"purchase_order_id"`
+	Status          string             `json:"status"`
+	Note            *string            `json:"note"`
+	TotalAmount     int                `json:"total_amount"`
+	UserID          *int               `json:"user_id"`
+	Username        *string            `json:"username,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	ReversedBy      *int               `json:"reversed_by"`
+	ReversedAt      *time.Time         `json:"reversed_at"`
+	ReverseReason   *string            `json:"reverse_reason"`
+	Items           []GoodsReceiptItem `json:"items,omitempty"`
+}
+
+// GoodsReceiptItem - UnitCost adalah harga beli aktual per unit, CostBefore / CostAfter harga pokok produk
+// sebelum dan sesudah baris ini di-posting (rata-rata bergerak)
+type GoodsReceiptItem struct {
+	ID                 int    `json:"id"`
+	ProductID          int    `json:"product_id"`
+	ProductName        string `json:"product_name"`
+	Quantity           int    `json:"quantity"`
+	UnitCost           int    `json:"unit_cost"`
+	Subtotal           int    `json:"subtotal"`
+	CostBefore         int    `json:"cost_before"`
+	CostAfter          int    `json:"cost_after"`
+	StockMovementID    int    `json:"stock_movement_id"`
+	ReversalMovementID *int   `json:"reversal_movement_id,omitempty"`
+}
+
+type GoodsReceiptItemRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+	UnitCost  int `json:"unit_cost"`
+}
+
+// GoodsReceiptRequest - SupplierName wajib bila SupplierID kosong, bila SupplierID diisi nama diambil dari
+// data supplier. PurchaseOrderID diisi oleh endpoint receive purchase order, bukan dari body request.
+type GoodsReceiptRequest struct {
+	SupplierID      *int                      `json:"supplier_id"`
+	SupplierName    string                    `json:"supplier_name"`
+	InvoiceRef      string                    `json:"invoice_ref"`
+	Note            string                    `json:"note"`
+	Items           []GoodsReceiptItemRequest `json:"items"`
+	PurchaseOrderID *int                      `json:"-"`
+	// UserID - pengguna yang login, diisi handler dan bukan dari body request
+	UserID *int `json:"-"`
+}
+
+type ReverseGoodsReceiptRequest struct {
+	Reason string `json:"reason"`
+	UserID *int   `json:"-"`
+}
+
+// GoodsReceiptFilter - filter daftar penerimaan barang, Supplier mencari di nama supplier
+type GoodsReceiptFilter struct {
+	StartDate       *time.Time
+	EndDate         *time.Time
+	SupplierID      *int
+	Supplier        string
+	PurchaseOrderID *int
+	Status          string
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyRecord - Idempotency-Key milik satu pengguna beserta hash request dan response aslinya.
+// ResponseBody kosong dengan TransactionID terisi berarti transaksi sudah tersimpan tetapi response-nya
+// belum sempat dicatat, response diputar ulang dari transaksi tersebut.
+type IdempotencyRecord struct {
+	UserID         *int
+	Key            string
+	RequestHash    string
+	Status         string
+	ResponseStatus int
+	ResponseBody   []byte
+	TransactionID  *int
+	CreatedAt      time.Time
+}
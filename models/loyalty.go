@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	LoyaltyEntryEarn           = "earn"
+	LoyaltyEntryRedeem         = "redeem"
+	LoyaltyEntryEarnReversal   = "earn_reversal"
+	LoyaltyEntryRedeemReversal = "redeem_reversal"
+	LoyaltyEntryExpire         = "expire"
+)
+
+// LoyaltyEntry - satu baris ledger poin, Points positif untuk poin masuk dan negatif untuk poin keluar
+type LoyaltyEntry struct {
+	ID            int        `json:"id"`
+	CustomerID    int        `json:"customer_id"`
+	TransactionID *int       `json:"transaction_id"`
+	RefundID      *int       `json:"refund_id"`
+	Type          string     `json:"type"`
+	Points        int        `json:"points"`
+	BalanceAfter  int        `json:"balance_after"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// LoyaltyBalance - saldo poin member beserta nilai rupiahnya bila dipakai sebagai pembayaran
+type LoyaltyBalance struct {
+	CustomerID       int     `json:"customer_id"`
+	CustomerName     string  `json:"customer_name"`
+	Phone            *string `json:"phone"`
+	Points           int     `json:"points"`
+	RedeemableAmount int     `json:"redeemable_amount"`
+}
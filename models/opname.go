@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+const (
+	OpnameStatusOpen      = "open"
+	OpnameStatusApproved  = "approved"
+	OpnameStatusCancelled = "cancelled"
+)
+
+// StockOpname - sesi hitung fisik stok. CategoryIDs kosong berarti seluruh produk.
+// Ringkasan variance dihitung dari Items, nilai memakai harga jual saat ini (dibekukan saat approve).
+type StockOpname struct {
+	ID               int               `json:"id"`
+	Status           string            `json:"status"`
+	CategoryIDs      []int64           `json:"category_ids"`
+	Note             *string           `json:"note"`
+	CreatedBy        *int              `json:"created_by"`
+	CreatedAt        time.Time         `json:"created_at"`
+	ApprovedBy       *int              `json:"approved_by"`
+	ApprovedAt       *time.Time        `json:"approved_at"`
+	CancelledAt      *time.Time        `json:"cancelled_at"`
+	TotalItems       int               `json:"total_items"`
+	CountedItems     int               `json:"counted_items"`
+	VarianceQuantity int               `json:"variance_quantity"`
+	VarianceValue    int               `json:"variance_value"`
+	ShortageValue    int               `json:"shortage_value"`
+	SurplusValue     int               `json:"surplus_value"`
+	Items            []StockOpnameItem `json:"items,omitempty"`
+}
+
+// StockOpnameItem - hasil produk diambil dari hitungan paling akhir di antara seluruh penghitung.
+// ExpectedQty adalah stok sistem saat hitungan itu dicatat, yaitu SnapshotStock ditambah
+// MovementsSinceSnapshot (penjualan, refund, dll. selama sesi). Variance = CountedQty - ExpectedQty.
+// Field hasil nil selama produk belum dihitung.
+type StockOpnameItem struct {
+	ProductID              int                `json:"product_id"`
+	ProductName            string             `json:"product_name"`
+	CategoryID             *int               `json:"category_id"`
+	SnapshotStock          int                `json:"snapshot_stock"`
+	CountedQty             *int               `json:"counted_qty"`
+	ExpectedQty            *int               `json:"expected_qty"`
+	MovementsSinceSnapshot *int               `json:"movements_since_snapshot"`
+	Variance               *int               `json:"variance"`
+	UnitPrice              int                `json:"unit_price"`
+	VarianceValue          *int               `json:"variance_value"`
+	StockMovementID        *int               `json:"stock_movement_id,omitempty"`
+	Counts                 []StockOpnameCount `json:"counts,omitempty"`
+}
+
+// StockOpnameCount - hitungan terakhir satu penghitung untuk satu produk. Penghitung adalah putaran
+// verifikasi yang menghitung seluruh stok produk, bukan zona; Variance = Quantity - SystemStock sehingga
+// hasil antar penghitung bisa dibandingkan walaupun ada penjualan di antaranya.
+type StockOpnameCount struct {
+	Counter     string    `json:"counter"`
+	Quantity    int       `json:"quantity"`
+	SystemStock int       `json:"system_stock"`
+	Variance    int       `json:"variance"`
+	UserID      *int      `json:"user_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type StockOpnameRequest struct {
+	CategoryIDs []int64 `json:"category_ids"`
+	Note        string  `json:"note"`
+	// UserID - pengguna yang login, diisi handler dan bukan dari body request
+	UserID *int `json:"-"`
+}
+
+type StockOpnameCountItem struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// StockOpnameCountRequest - satu putaran hitungan. Counter kosong memakai username yang login,
+// hitungan ulang oleh counter yang sama menggantikan hitungan sebelumnya. Quantity adalah seluruh stok
+// fisik produk; produk yang tersimpan di beberapa lokasi dijumlahkan penghitung sebelum dikirim.
+type StockOpnameCountRequest struct {
+	Counter string                 `json:"counter"`
+	Items   []StockOpnameCountItem `json:"items"`
+	UserID  *int                   `json:"-"`
+}
+
+// ApproveOpnameRequest - UncountedAsZero = true menganggap produk yang belum dihitung stoknya 0,
+// bila false produk tersebut dilewati tanpa penyesuaian
+type ApproveOpnameRequest struct {
+	UncountedAsZero bool `json:"uncounted_as_zero"`
+	UserID          *int `json:"-"`
+}
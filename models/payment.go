@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	PaymentMethodCash     = "cash"
+	PaymentMethodDebit    = "debit"
+	PaymentMethodQRIS     = "qris"
+	PaymentMethodEWallet  = "e_wallet"
+	PaymentMethodTransfer = "transfer"
+	// poin loyalti member, amount dalam rupiah sesuai nilai tukar poin
+	PaymentMethodPoints = "points"
+
+	PaymentStatusPaid    = "paid"
+	PaymentStatusPending = "pending"
+	PaymentStatusExpired = "expired"
+	PaymentStatusFailed  = "failed"
+)
+
+// PaymentInput - satu tender pembayaran pada request checkout
+type PaymentInput struct {
+	Method    string `json:"method"`
+	Amount    int    `json:"amount"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// Payment - Amount adalah nominal yang masuk ke penjualan (tunai sudah dikurangi kembalian),
+// TenderedAmount nominal yang diserahkan pelanggan
+type Payment struct {
+	ID             int       `json:"id"`
+	TransactionID  int       `json:"transaction_id"`
+	Method         string    `json:"method"`
+	Amount         int       `json:"amount"`
+	TenderedAmount int       `json:"tendered_amount"`
+	Reference      *string   `json:"reference,omitempty"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PaymentIntent - permintaan pembayaran ke payment gateway untuk tender QRIS / e-wallet.
+// Transaksi tetap pending (stok sudah direservasi) sampai callback gateway menyatakan lunas.
+type PaymentIntent struct {
+	ID            int        `json:"id"`
+	TransactionID int        `json:"transaction_id"`
+	PaymentID     int        `json:"payment_id"`
+	Gateway       string     `json:"gateway"`
+	ExternalID    *string    `json:"external_id"`
+	QRString      *string    `json:"qr_string,omitempty"`
+	Amount        int        `json:"amount"`
+	Status        string     `json:"status"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// PaymentCallback - hasil parsing callback payment gateway yang sudah terverifikasi
+type PaymentCallback struct {
+	ExternalID string
+	Status     string
+	PaidAt     time.Time
+}
+
+// PaymentMethodSummary - total pembayaran per metode pada laporan
+type PaymentMethodSummary struct {
+	Method string `json:"method"`
+	Amount int    `json:"amount"`
+	Count  int    `json:"count"`
+}
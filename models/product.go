@@ -1,10 +1,14 @@
 package models
 
+// Product - CostPrice adalah harga pokok rata-rata bergerak, hanya diisi manual saat produk dibuat
+// dan selanjutnya diperbarui oleh penerimaan barang
 type Product struct {
 	ID           int     `json:"id"`
 	Name         string  `json:"name"`
 	Price        int     `json:"price"`
 	Stock        int     `json:"stock"`
+	CostPrice    int     `json:"cost_price"`
 	CategoryID   *int    `json:"category_id"`
 	CategoryName *string `json:"category_name,omitempty"`
+	TaxClassID   *int    `json:"tax_class_id"`
 }
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+const (
+	PromotionTypePercentage = "percentage"
+	PromotionTypeFixed      = "fixed"
+	PromotionTypeBuyXGetY   = "buy_x_get_y"
+)
+
+// Promotion - promo berlaku untuk satu produk (ProductID), satu kategori (CategoryID),
+// atau seluruh belanja bila keduanya kosong. Value berupa persen untuk tipe percentage
+// dan rupiah untuk tipe fixed (per unit bila untuk produk/kategori).
+type Promotion struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Value      int        `json:"value"`
+	ProductID  *int       `json:"product_id"`
+	CategoryID *int       `json:"category_id"`
+	BuyQty     int        `json:"buy_qty"`
+	GetQty     int        `json:"get_qty"`
+	StartAt    *time.Time `json:"start_at"`
+	EndAt      *time.Time `json:"end_at"`
+	Active     bool       `json:"active"`
+}
+
+// LineDiscount - diskon promo yang diterapkan pada satu baris transaksi
+type LineDiscount struct {
+	ID            int    `json:"id"`
+	PromotionID   *int   `json:"promotion_id"`
+	PromotionName string `json:"promotion_name"`
+	Amount        int    `json:"amount"`
+}
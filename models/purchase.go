@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+// Supplier - PaymentTermDays adalah tempo pembayaran dalam hari, 0 berarti tunai
+type Supplier struct {
+	ID              int       `json:"id"`
+	Name            string    `json:"name"`
+	ContactName     *string   `json:"contact_name"`
+	Phone           *string   `json:"phone"`
+	Email           *string   `json:"email"`
+	Address         *string   `json:"address"`
+	PaymentTermDays int       `json:"payment_term_days"`
+	Active          bool      `json:"active"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// SupplierRequest - Active nil berarti tidak diubah (default aktif saat dibuat)
+type SupplierRequest struct {
+	Name            string  `json:"name"`
+	ContactName     *string `json:"contact_name"`
+	Phone           *string `json:"phone"`
+	Email           *string `json:"email"`
+	Address         *string `json:"address"`
+	PaymentTermDays int     `json:"payment_term_days"`
+	Active          *bool   `json:"active"`
+}
+
+// PurchaseOrder - TotalAmount adalah jumlah quantity * unit_cost seluruh baris
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	SupplierID   int                 `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	Status       string              `json:"status"`
+	ExpectedDate *time.Time          `json:"expected_date"`
+	Note         *string             `json:"note"`
+	TotalAmount  int                 `json:"total_amount"`
+	CreatedBy    *int                `json:"created_by"`
+	CreatedAt    time.Time           `json:"created_at"`
+	SentAt       *time.Time          `json:"sent_at"`
+	ReceivedAt   *time.Time          `json:"received_at"`
+	CancelledAt  *time.Time          `json:"cancelled_at"`
+	CancelReason *string             `json:"cancel_reason"`
+	Items        []PurchaseOrderItem `json:"items,omitempty"`
+}
+
+// PurchaseOrderItem - UnitCost adalah perkiraan harga beli, ReceivedQty bertambah setiap penerimaan barang
+type PurchaseOrderItem struct {
+	ID          int    `json:"id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	UnitCost    int    `json:"unit_cost"`
+	Subtotal    int    `json:"subtotal"`
+	ReceivedQty int    `json:"received_qty"`
+}
+
+type PurchaseOrderItemRequest struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+	UnitCost  int `json:"unit_cost"`
+}
+
+// PurchaseOrderRequest - ExpectedDate format YYYY-MM-DD, baris hanya bisa diubah selama draft
+type PurchaseOrderRequest struct {
+	SupplierID   int                        `json:"supplier_id"`
+	ExpectedDate *string                    `json:"expected_date"`
+	Note         *string                    `json:"note"`
+	Items        []PurchaseOrderItemRequest `json:"items"`
+	// UserID - pengguna yang login, diisi handler dan bukan dari body request
+	UserID *int `json:"-"`
+}
+
+// ReceivePurchaseOrderItem - UnitCost nil memakai harga perkiraan di baris purchase order
+type ReceivePurchaseOrderItem struct {
+	ProductID int  `json:"product_id"`
+	Quantity  int  `json:"quantity"`
+	UnitCost  *int `json:"unit_cost"`
+}
+
+// ReceivePurchaseOrderRequest - barang yang datang dicatat sebagai goods receipt,
+// quantity tidak boleh melebihi sisa yang belum diterima
+type ReceivePurchaseOrderRequest struct {
+	InvoiceRef string                     `json:"invoice_ref"`
+	Note       string                     `json:"note"`
+	Items      []ReceivePurchaseOrderItem `json:"items"`
+	UserID     *int                       `json:"-"`
+}
+
+type CancelPurchaseOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+// PurchaseOrderFilter - Status "open" berarti sent dan partially_received
+type PurchaseOrderFilter struct {
+	Status     string
+	SupplierID *int
+}
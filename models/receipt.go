@@ -0,0 +1,15 @@
+package models
+
+const (
+	ReceiptFormatText   = "text"
+	ReceiptFormatESCPOS = "escpos"
+	ReceiptFormatPDF    = "pdf"
+)
+
+// ShopProfile - identitas toko yang dicetak di header dan footer struk
+type ShopProfile struct {
+	Name    string
+	Address string
+	Phone   string
+	Footer  string
+}
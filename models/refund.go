@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+const (
+	RefundTypeVoid   = "void"
+	RefundTypeRefund = "refund"
+)
+
+// Refund - CreditedAmount adalah bagian TotalAmount yang memotong sisa kasbon transaksi,
+// sisanya dikembalikan langsung ke pelanggan lewat tender asal (Payments)
+type Refund struct {
+	ID             int             `json:"id"`
+	TransactionID  int             `json:"transaction_id"`
+	Type           string          `json:"type"`
+	Reason         string          `json:"reason"`
+	TotalAmount    int             `json:"total_amount"`
+	CreditedAmount int             `json:"credited_amount"`
+	ShiftID        *int            `json:"shift_id"`
+	UserID         *int            `json:"user_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Details        []RefundDetail  `json:"details"`
+	Payments       []RefundPayment `json:"payments"`
+}
+
+type RefundDetail struct {
+	ID                  int `json:"id"`
+	RefundID            int `json:"refund_id"`
+	TransactionDetailID int `json:"transaction_detail_id"`
+	ProductID           int `json:"product_id"`
+	Quantity            int `json:"quantity"`
+	Amount              int `json:"amount"`
+}
+
+// RefundPayment - bagian refund yang dikembalikan lewat satu metode pembayaran asal.
+// Untuk metode points, Points adalah jumlah poin yang dikembalikan ke saldo member senilai Amount.
+type RefundPayment struct {
+	Method string `json:"method"`
+	Amount int    `json:"amount"`
+	Points int    `json:"points,omitempty"`
+}
+
+type RefundItem struct {
+	TransactionDetailID int `json:"transaction_detail_id"`
+	Quantity            int `json:"quantity"`
+}
+
+// RefundRequest - Items hanya dipakai untuk refund sebagian, void selalu membalik seluruh sisa transaksi
+type RefundRequest struct {
+	Reason string       `json:"reason"`
+	Items  []RefundItem `json:"items"`
+	// UserID - pengguna yang login, diisi handler dan bukan dari body request
+	UserID *int `json:"-"`
+}
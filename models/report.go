@@ -1,12 +1,64 @@
 package models
 
+import "time"
+
+// ReportResponse - TotalRevenue adalah revenue bersih: GrossSales - TotalDiscount - TotalRefund,
+// ditambah pajak yang tidak termasuk dalam harga
 type ReportResponse struct {
-	TotalRevenue    int             `json:"total_revenue"`
-	TotalTransaksi  int             `json:"total_transaksi"`
-	ProdukTerlaris  *ProdukTerlaris `json:"produk_terlaris"`
+	GrossSales     int                    `json:"gross_sales"`
+	TotalDiscount  int                    `json:"total_discount"`
+	TotalRefund    int                    `json:"total_refund"`
+	TotalRevenue   int                    `json:"total_revenue"`
+	TotalTax       int                    `json:"total_tax"`
+	TaxSummary     []TaxSummary           `json:"tax_summary"`
+	PaymentMethods []PaymentMethodSummary `json:"payment_methods"`
+	TotalTransaksi int                    `json:"total_transaksi"`
+	ProdukTerlaris *ProdukTerlaris        `json:"produk_terlaris"`
+}
+
+// ReportFilter - rentang tanggal [StartDate, EndDate) dan filter opsional laporan
+type ReportFilter struct {
+	StartDate  time.Time
+	EndDate    time.Time
+	CustomerID *int
+}
+
+// SalesSummary - komponen penjualan dalam rentang tanggal, TotalRefund adalah refund / void yang dibuat
+// dalam rentang tersebut walaupun transaksi asalnya dari tanggal lain
+type SalesSummary struct {
+	GrossSales    int
+	TotalDiscount int
+	TotalRefund   int
 }
 
 type ProdukTerlaris struct {
 	Nama       string `json:"nama"`
 	QtyTerjual int    `json:"qty_terjual"`
 }
+
+const (
+	ReportTypeX = "X"
+	ReportTypeZ = "Z"
+)
+
+// EndOfDayReport - laporan X (snapshot berjalan) dan Z (tutup hari) untuk satu tanggal bisnis.
+// AverageBasket = TotalRevenue / TotalTransaksi. ZNumber hanya terisi pada laporan Z yang sudah disegel.
+type EndOfDayReport struct {
+	Type         string    `json:"type"`
+	ZNumber      *int      `json:"z_number,omitempty"`
+	BusinessDate string    `json:"business_date"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	ReportResponse
+	ItemCount     int           `json:"item_count"`
+	AverageBasket int           `json:"average_basket"`
+	Hourly        []HourlySales `json:"hourly"`
+	FirstInvoice  *string       `json:"first_invoice"`
+	LastInvoice   *string       `json:"last_invoice"`
+}
+
+// HourlySales - penjualan bersih per jam, hanya jam yang memiliki transaksi
+type HourlySales struct {
+	Hour           int `json:"hour"`
+	TotalTransaksi int `json:"total_transaksi"`
+	TotalRevenue   int `json:"total_revenue"`
+}
@@ -0,0 +1,49 @@
+package models
+
+// Kode permission yang dicek middleware, daftar lengkap dan deskripsinya ada di tabel permissions
+const (
+	PermissionCatalogRead       = "catalog.read"
+	PermissionCatalogWrite      = "catalog.write"
+	PermissionCheckout          = "checkout"
+	PermissionTransactionRead   = "transaction.read"
+	PermissionTransactionRefund = "transaction.refund"
+	PermissionShiftOperate      = "shift.operate"
+	PermissionCustomerRead      = "customer.read"
+	PermissionCustomerWrite     = "customer.write"
+	PermissionDebtRead          = "debt.read"
+	PermissionDebtRepay         = "debt.repay"
+	PermissionDebtManage        = "debt.manage"
+	PermissionReportRead        = "report.read"
+	PermissionReportClose       = "report.close"
+	PermissionUserManage        = "user.manage"
+	PermissionStockAdjust       = "stock.adjust"
+	PermissionStockCount        = "stock.count"
+	PermissionPurchaseRead      = "purchase.read"
+	PermissionPurchaseManage    = "purchase.manage"
+)
+
+// RoleOwner - role bawaan yang selalu memiliki seluruh permission dan tidak bisa diubah
+const RoleOwner = "owner"
+
+type Permission struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+type Role struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UserRolesRequest - daftar nama role pengganti seluruh role pengguna
+type UserRolesRequest struct {
+	Roles []string `json:"roles"`
+}
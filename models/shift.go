@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	ShiftStatusOpen   = "open"
+	ShiftStatusClosed = "closed"
+
+	CashMovementIn  = "cash_in"
+	CashMovementOut = "cash_out"
+)
+
+// Shift - CountedCash dan ExpectedCash diisi saat shift ditutup
+type Shift struct {
+	ID           int        `json:"id"`
+	CashierName  string     `json:"cashier_name"`
+	OpeningFloat int        `json:"opening_float"`
+	Status       string     `json:"status"`
+	CountedCash  *int       `json:"counted_cash"`
+	ExpectedCash *int       `json:"expected_cash"`
+	CloseNote    *string    `json:"close_note"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+}
+
+type OpenShiftRequest struct {
+	CashierName  string `json:"cashier_name"`
+	OpeningFloat int    `json:"opening_float"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash *int    `json:"counted_cash"`
+	Note        *string `json:"note"`
+}
+
+// CashMovement - kas masuk / keluar laci di luar penjualan, misalnya kas kecil
+type CashMovement struct {
+	ID        int       `json:"id"`
+	ShiftID   int       `json:"shift_id"`
+	Type      string    `json:"type"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CashMovementRequest struct {
+	Type   string `json:"type"`
+	Amount int    `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+// ShiftReport - ExpectedCash = OpeningFloat + CashSales + CashIn - CashOut - CashRefunds + DebtRepayments.
+// Refund dianggap dibayar tunai dari laci, DebtRepayments hanya cicilan kasbon tunai.
+// ActualCash dan Difference (ActualCash - ExpectedCash) kosong selama shift masih open.
+type ShiftReport struct {
+	Shift             Shift                  `json:"shift"`
+	TotalTransactions int                    `json:"total_transactions"`
+	PaymentMethods    []PaymentMethodSummary `json:"payment_methods"`
+	CashSales         int                    `json:"cash_sales"`
+	CashIn            int                    `json:"cash_in"`
+	CashOut           int                    `json:"cash_out"`
+	CashRefunds       int                    `json:"cash_refunds"`
+	DebtRepayments    int                    `json:"debt_repayments"`
+	ExpectedCash      int                    `json:"expected_cash"`
+	ActualCash        *int                   `json:"actual_cash"`
+	Difference        *int                   `json:"difference"`
+	CashMovements     []CashMovement         `json:"cash_movements"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+const (
+	StockMovementSale       = "sale"
+	StockMovementRefund     = "refund"
+	StockMovementAdjustment = "adjustment"
+	StockMovementReceipt    = "receipt"
+	StockMovementTransfer   = "transfer"
+	StockMovementOpname     = "opname"
+
+	// dokumen sumber movement
+	StockReferenceOpening     = "opening"
+	StockReferenceProduct     = "product"
+	StockReferenceTransaction = "transaction"
+	StockReferenceRefund      = "refund"
+	StockReferenceAdjustment  = "stock_adjustment"
+	StockReferenceOpname      = "stock_opname"
+	StockReferenceReceipt     = "goods_receipt"
+
+	AdjustmentReasonDamaged    = "damaged"
+	AdjustmentReasonExpired    = "expired"
+	AdjustmentReasonLost       = "lost"
+	AdjustmentReasonFound      = "found"
+	AdjustmentReasonCorrection = "correction"
+)
+
+// StockMovement - satu baris kartu stok. Quantity positif menambah stok, negatif mengurangi.
+// BalanceAfter adalah stok produk setelah movement ini diterapkan.
+type StockMovement struct {
+	ID            int       `json:"id"`
+	ProductID     int       `json:"product_id"`
+	Type          string    `json:"type"`
+	Quantity      int       `json:"quantity"`
+	BalanceAfter  int       `json:"balance_after"`
+	ReferenceType string    `json:"reference_type"`
+	ReferenceID   *int      `json:"reference_id"`
+	Note          *string   `json:"note"`
+	UserID        *int      `json:"user_id"`
+	Username      *string   `json:"username,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// StockMovementFilter - filter riwayat movement satu produk
+type StockMovementFilter struct {
+	Type      string
+	StartDate *time.Time
+	EndDate   *time.Time
+}
+
+// StockMovementHistory - kartu stok produk, Stock adalah saldo saat ini
+type StockMovementHistory struct {
+	ProductID   int             `json:"product_id"`
+	ProductName string          `json:"product_name"`
+	Stock       int             `json:"stock"`
+	Movements   []StockMovement `json:"movements"`
+}
+
+// StockAdjustment - dokumen penyesuaian stok, seluruh baris diterapkan dalam satu transaksi database
+type StockAdjustment struct {
+	ID        int                   `json:"id"`
+	Note      *string               `json:"note"`
+	UserID    *int                  `json:"user_id"`
+	Username  *string               `json:"username,omitempty"`
+	CreatedAt time.Time             `json:"created_at"`
+	Items     []StockAdjustmentItem `json:"items"`
+}
+
+// StockAdjustmentItem - Quantity negatif untuk damaged / expired / lost, positif untuk found,
+// correction boleh keduanya. BalanceAfter adalah stok setelah baris ini diterapkan.
+type StockAdjustmentItem struct {
+	ID              int    `json:"id"`
+	ProductID       int    `json:"product_id"`
+	ProductName     string `json:"product_name"`
+	Quantity        int    `json:"quantity"`
+	Reason          string `json:"reason"`
+	BalanceAfter    int    `json:"balance_after"`
+	StockMovementID int    `json:"stock_movement_id"`
+}
+
+type StockAdjustmentItemRequest struct {
+	ProductID int    `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`
+}
+
+type StockAdjustmentRequest struct {
+	Note  string                       `json:"note"`
+	Items []StockAdjustmentItemRequest `json:"items"`
+	// UserID - pengguna yang login, diisi handler dan bukan dari body request
+	UserID *int `json:"-"`
+}
+
+// StockAdjustmentFilter - filter daftar penyesuaian stok, EndDate eksklusif
+type StockAdjustmentFilter struct {
+	StartDate *time.Time
+	EndDate   *time.Time
+	Page      int
+	Limit     int
+}
+
+// StockAdjustmentListResponse - response daftar penyesuaian stok dengan pagination
+type StockAdjustmentListResponse struct {
+	Data  []StockAdjustment `json:"data"`
+	Page  int               `json:"page"`
+	Limit int               `json:"limit"`
+	Total int               `json:"total"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// TaxClass - Rate dalam persen (11 = PPN 11%). PriceIncludesTax = true berarti harga
+// produk sudah termasuk pajak, false berarti pajak ditambahkan di atas harga.
+type TaxClass struct {
+	ID               int     `json:"id"`
+	Name             string  `json:"name"`
+	Rate             float64 `json:"rate"`
+	PriceIncludesTax bool    `json:"price_includes_tax"`
+}
+
+// TaxSummary - ringkasan pajak per kelas pajak pada laporan
+type TaxSummary struct {
+	TaxClassName  string  `json:"tax_class_name"`
+	Rate          float64 `json:"rate"`
+	TaxableAmount int     `json:"taxable_amount"`
+	TaxAmount     int     `json:"tax_amount"`
+}
@@ -2,27 +2,124 @@ package models
 
 import "time"
 
+const (
+	// pending: menunggu konfirmasi payment gateway, expired: tidak dibayar sampai batas waktu
+	TransactionStatusPending           = "pending"
+	TransactionStatusExpired           = "expired"
+	TransactionStatusCompleted         = "completed"
+	TransactionStatusPartiallyRefunded = "partially_refunded"
+	TransactionStatusRefunded          = "refunded"
+	TransactionStatusVoided            = "voided"
+)
+
+// Transaction - SubtotalAmount adalah total sebelum diskon, TotalAmount total bersih yang dibayar
+// termasuk pajak yang tidak termasuk dalam harga (TaxAmount mencakup pajak inklusif dan eksklusif)
 type Transaction struct {
-	ID          int                 `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID             int                 `json:"id"`
+	InvoiceNumber  string              `json:"invoice_number"`
+	CustomerID     *int                `json:"customer_id"`
+	CustomerName   *string             `json:"customer_name,omitempty"`
+	ShiftID        *int                `json:"shift_id"`
+	UserID         *int                `json:"user_id"`
+	SubtotalAmount int                 `json:"subtotal_amount"`
+	DiscountAmount int                 `json:"discount_amount"`
+	TaxAmount      int                 `json:"tax_amount"`
+	TotalAmount    int                 `json:"total_amount"`
+	PaidAmount     int                 `json:"paid_amount"`
+	ChangeAmount   int                 `json:"change_amount"`
+	PointsEarned   int                 `json:"points_earned"`
+	PointsRedeemed int                 `json:"points_redeemed"`
+	PointsExpireAt *time.Time          `json:"points_expire_at,omitempty"`
+	Status         string              `json:"status"`
+	CreatedAt      time.Time           `json:"created_at"`
+	Details        []TransactionDetail `json:"details,omitempty"`
+	Payments       []Payment           `json:"payments,omitempty"`
+	PaymentIntent  *PaymentIntent      `json:"payment_intent,omitempty"`
+	Receivable     *Receivable         `json:"receivable,omitempty"`
+}
+
+// CalculateTotals - jumlahkan subtotal, diskon, pajak dan total dari baris transaksi
+func (t *Transaction) CalculateTotals() {
+	t.SubtotalAmount, t.DiscountAmount, t.TaxAmount, t.TotalAmount = 0, 0, 0, 0
+	for _, d := range t.Details {
+		t.SubtotalAmount += d.Subtotal
+		t.DiscountAmount += d.DiscountAmount
+		t.TaxAmount += d.TaxAmount
+		t.TotalAmount += d.LineTotal()
+	}
 }
 
+// TransactionDetail - Subtotal adalah UnitPrice * Quantity sebelum diskon
 type TransactionDetail struct {
-	ID            int    `json:"id"`
-	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name,omitempty"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	ID             int            `json:"id"`
+	TransactionID  int            `json:"transaction_id"`
+	ProductID      int            `json:"product_id"`
+	ProductName    string         `json:"product_name"`
+	CategoryID     *int           `json:"-"`
+	UnitPrice      int            `json:"unit_price"`
+	Quantity       int            `json:"quantity"`
+	Subtotal       int            `json:"subtotal"`
+	DiscountAmount int            `json:"discount_amount"`
+	Discounts      []LineDiscount `json:"discounts,omitempty"`
+	TaxClassID     *int           `json:"tax_class_id"`
+	TaxClassName   *string        `json:"tax_class_name,omitempty"`
+	TaxRate        float64        `json:"tax_rate"`
+	TaxInclusive   bool           `json:"tax_inclusive"`
+	TaxAmount      int            `json:"tax_amount"`
+}
+
+// LineTotal - nilai akhir baris setelah diskon, ditambah pajak bila harga belum termasuk pajak
+func (d TransactionDetail) LineTotal() int {
+	total := d.Subtotal - d.DiscountAmount
+	if !d.TaxInclusive {
+		total += d.TaxAmount
+	}
+	return total
 }
 
 type CheckoutItem struct {
 	ProductID int `json:"product_id"`
 	Quantity  int `json:"quantity"`
+	// Index - posisi baris pertama produk ini di request, diisi ValidateCheckoutItems saat baris digabung
+	Index int `json:"-"`
 }
 
+// CheckoutRequest - Payments boleh kosong, checkout tanpa pembayaran dicatat sebagai tunai pas.
+// CustomerID opsional, menghubungkan transaksi ke data pelanggan.
+// MemberPhone opsional, member dicari dari nomor telepon untuk mendapat / memakai poin.
+// DebtorID opsional untuk kasbon, sisa total setelah uang muka (payments) dicatat sebagai piutang debitur.
 type CheckoutRequest struct {
-	Items   []CheckoutItem `json:"items"`
-}
\ No newline at end of file
+	Items       []CheckoutItem `json:"items"`
+	Payments    []PaymentInput `json:"payments"`
+	CustomerID  *int           `json:"customer_id"`
+	MemberPhone string         `json:"member_phone"`
+	DebtorID    *int           `json:"debtor_id"`
+	// UserID - pengguna yang login, diisi handler dan bukan dari body request
+	UserID *int `json:"-"`
+	// IdempotencyKey - header Idempotency-Key, diisi handler dan ditandai selesai di dalam transaksi checkout
+	IdempotencyKey string `json:"-"`
+	// CartID - keranjang asal, diisi CartService dan ditandai finalized di dalam transaksi checkout
+	CartID *int `json:"-"`
+}
+
+// TransactionFilter - filter untuk daftar riwayat transaksi
+type TransactionFilter struct {
+	StartDate     *time.Time
+	EndDate       *time.Time
+	MinAmount     *int
+	MaxAmount     *int
+	ProductID     *int
+	InvoiceNumber string
+	CustomerID    *int
+	ShiftID       *int
+	Page          int
+	Limit         int
+}
+
+// TransactionListResponse - response daftar transaksi dengan pagination
+type TransactionListResponse struct {
+	Data  []Transaction `json:"data"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+	Total int           `json:"total"`
+}
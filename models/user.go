@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// User - akun kasir / pengelola toko, PasswordHash tidak pernah dikirim di response.
+// Permissions hanya diisi untuk pengguna yang sedang login.
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	Name         string    `json:"name"`
+	Active       bool      `json:"active"`
+	Roles        []string  `json:"roles"`
+	Permissions  []string  `json:"permissions,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	PasswordHash string    `json:"-"`
+}
+
+// Can - pengguna memiliki permission tersebut lewat salah satu role-nya
+func (u *User) Can(permission string) bool {
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// UserRequest - Password kosong saat update berarti password tidak diubah, Active nil berarti tidak diubah.
+// Roles hanya dipakai saat membuat pengguna, perubahan role lewat PUT /api/users/{id}/roles.
+type UserRequest struct {
+	Username string   `json:"username"`
+	Name     string   `json:"name"`
+	Password string   `json:"password"`
+	Active   *bool    `json:"active"`
+	Roles    []string `json:"roles"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse - ExpiresIn adalah umur access token dalam detik
+type TokenResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int       `json:"expires_in"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	User             User      `json:"user"`
+}
@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"time"
+)
+
+// ErrCartNotFound - keranjang dengan id tersebut tidak ada
+var ErrCartNotFound = errors.New("keranjang tidak ditemukan")
+
+// ErrCartStatus - aksi tidak bisa dilakukan pada status keranjang saat ini
+var ErrCartStatus = errors.New("status keranjang tidak sesuai")
+
+// ErrCartItemNotFound - produk tidak ada di keranjang
+var ErrCartItemNotFound = errors.New("produk tidak ada di keranjang")
+
+type CartRepository struct {
+	db *sql.DB
+}
+
+func NewCartRepository(db *sql.DB) *CartRepository {
+	return &CartRepository{db: db}
+}
+
+func (repo *CartRepository) Create(cart *models.Cart) error {
+	query := "INSERT INTO carts (label, status, expires_at) VALUES ($1, $2, $3) RETURNING id, created_at, updated_at"
+	return repo.db.QueryRow(query, cart.Label, cart.Status, cart.ExpiresAt).Scan(&cart.ID, &cart.CreatedAt, &cart.UpdatedAt)
+}
+
+// GetAll - daftar keranjang tanpa item, status kosong berarti keranjang open dan held
+func (repo *CartRepository) GetAll(status string) ([]models.Cart, error) {
+	query := "SELECT id, label, status, transaction_id, created_at, updated_at, expires_at FROM carts"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status)
+	} else {
+		query += fmt.Sprintf(" WHERE status IN ('%s', '%s')", models.CartStatusOpen, models.CartStatusHeld)
+	}
+	query += " ORDER BY updated_at DESC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	carts := make([]models.Cart, 0)
+	for rows.Next() {
+		var c models.Cart
+		err := rows.Scan(&c.ID, &c.Label, &c.Status, &c.TransactionID, &c.CreatedAt, &c.UpdatedAt, &c.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, c)
+	}
+
+	return carts, rows.Err()
+}
+
+// GetByID - keranjang beserta item dengan harga dan stok produk saat ini
+func (repo *CartRepository) GetByID(id int) (*models.Cart, error) {
+	var c models.Cart
+	err := repo.db.QueryRow("SELECT id, label, status, transaction_id, created_at, updated_at, expires_at FROM carts WHERE id = $1", id).
+		Scan(&c.ID, &c.Label, &c.Status, &c.TransactionID, &c.CreatedAt, &c.UpdatedAt, &c.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ci.id, ci.product_id, p.name, p.price, ci.quantity, p.stock
+		FROM cart_items ci
+		INNER JOIN products p ON ci.product_id = p.id
+		WHERE ci.cart_id = $1
+		ORDER BY ci.id
+	`
+	rows, err := repo.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	c.Items = make([]models.CartItem, 0)
+	for rows.Next() {
+		var item models.CartItem
+		err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.UnitPrice, &item.Quantity, &item.Stock)
+		if err != nil {
+			return nil, err
+		}
+		c.Items = append(c.Items, item)
+	}
+
+	return &c, rows.Err()
+}
+
+// UpsertItem - tambah produk ke keranjang open; increment = true menambah quantity yang sudah ada,
+// false mengganti quantity. expiresAt memperpanjang masa berlaku keranjang.
+func (repo *CartRepository) UpsertItem(cartID, productID, quantity int, increment bool, expiresAt time.Time) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenCart(tx, cartID); err != nil {
+		return err
+	}
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return &models.ValidationError{Message: "produk tidak ditemukan", Items: []models.ItemError{{ProductID: productID, Field: "product_id", Reason: "produk tidak ditemukan"}}}
+	}
+
+	query := `INSERT INTO cart_items (cart_id, product_id, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = EXCLUDED.quantity`
+	if increment {
+		query = `INSERT INTO cart_items (cart_id, product_id, quantity) VALUES ($1, $2, $3)
+			ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity`
+	}
+	if _, err := tx.Exec(query, cartID, productID, quantity); err != nil {
+		return err
+	}
+
+	if err := touchCart(tx, cartID, expiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveItem - hapus produk dari keranjang open
+func (repo *CartRepository) RemoveItem(cartID, productID int, expiresAt time.Time) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenCart(tx, cartID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2", cartID, productID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrCartItemNotFound
+	}
+
+	if err := touchCart(tx, cartID, expiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStatus - pindahkan status keranjang dari salah satu status asal, misalnya open -> held
+func (repo *CartRepository) UpdateStatus(cartID int, from []string, to string, expiresAt time.Time) error {
+	var current string
+	err := repo.db.QueryRow("SELECT status FROM carts WHERE id = $1", cartID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrCartNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{to, expiresAt, cartID}
+	placeholders := ""
+	for i, status := range from {
+		if i > 0 {
+			placeholders += ", "
+		}
+		args = append(args, status)
+		placeholders += fmt.Sprintf("$%d", len(args))
+	}
+
+	result, err := repo.db.Exec(fmt.Sprintf("UPDATE carts SET status = $1, expires_at = $2, updated_at = NOW() WHERE id = $3 AND status IN (%s)", placeholders), args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: keranjang berstatus %s", ErrCartStatus, current)
+	}
+
+	return nil
+}
+
+// RecoverFinalizing - buka kembali keranjang yang tertahan finalizing sejak sebelum before. Keranjang
+// ditandai finalized di dalam transaksi checkout, jadi keranjang yang masih finalizing belum punya transaksi.
+// Checkout yang sedang berjalan memegang lock baris keranjang sehingga update ini menunggu lalu dilewati.
+func (repo *CartRepository) RecoverFinalizing(before, expiresAt time.Time) (int64, error) {
+	result, err := repo.db.Exec("UPDATE carts SET status = $1, expires_at = $2, updated_at = NOW() WHERE status = $3 AND updated_at < $4",
+		models.CartStatusOpen, expiresAt, models.CartStatusFinalizing, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Reopen - buka kembali keranjang finalized yang transaksinya batal sebelum dibayar (intent payment
+// gateway gagal dan stoknya sudah dilepas), hubungan ke transaksi tersebut dihapus
+func (repo *CartRepository) Reopen(cartID, transactionID int, expiresAt time.Time) error {
+	_, err := repo.db.Exec("UPDATE carts SET status = $1, transaction_id = NULL, expires_at = $2, updated_at = NOW() WHERE id = $3 AND status = $4 AND transaction_id = $5",
+		models.CartStatusOpen, expiresAt, cartID, models.CartStatusFinalized, transactionID)
+	return err
+}
+
+// ExpireAbandoned - tandai keranjang open / held yang melewati expires_at sebagai expired
+func (repo *CartRepository) ExpireAbandoned(now time.Time) (int64, error) {
+	result, err := repo.db.Exec("UPDATE carts SET status = $1, updated_at = NOW() WHERE status IN ($2, $3) AND expires_at <= $4",
+		models.CartStatusExpired, models.CartStatusOpen, models.CartStatusHeld, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// lockFinalizingCart - kunci keranjang yang sedang di-checkout di awal transaksi checkout, ditolak bila
+// keranjang sudah tidak finalizing (misalnya sudah dibuka kembali atau di-checkout request lain)
+func lockFinalizingCart(tx *sql.Tx, cartID int) error {
+	var status string
+	err := tx.QueryRow("SELECT status FROM carts WHERE id = $1 FOR UPDATE", cartID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrCartNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.CartStatusFinalizing {
+		return fmt.Errorf("%w: keranjang berstatus %s", ErrCartStatus, status)
+	}
+	return nil
+}
+
+// finalizeCart - hubungkan keranjang dengan transaksi hasil checkout, di dalam transaksi checkout yang sama
+func finalizeCart(tx *sql.Tx, cartID, transactionID int) error {
+	_, err := tx.Exec("UPDATE carts SET status = $1, transaction_id = $2, updated_at = NOW() WHERE id = $3",
+		models.CartStatusFinalized, transactionID, cartID)
+	return err
+}
+
+// lockOpenCart - kunci keranjang dan pastikan statusnya open
+func lockOpenCart(tx *sql.Tx, cartID int) error {
+	var status string
+	err := tx.QueryRow("SELECT status FROM carts WHERE id = $1 FOR UPDATE", cartID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrCartNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.CartStatusOpen {
+		return fmt.Errorf("%w: keranjang berstatus %s, hanya keranjang open yang bisa diubah", ErrCartStatus, status)
+	}
+	return nil
+}
+
+func touchCart(tx *sql.Tx, cartID int, expiresAt time.Time) error {
+	_, err := tx.Exec("UPDATE carts SET updated_at = NOW(), expires_at = $1 WHERE id = $2", expiresAt, cartID)
+	return err
+}
@@ -15,7 +15,7 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 }
 
 func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
-	query := "SELECT id, name FROM categories"
+	query := "SELECT id, name, tax_class_id FROM categories"
 	rows, err := repo.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -25,7 +25,7 @@ func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 	categories := make([]models.Category, 0)
 	for rows.Next() {
 		var c models.Category
-		err := rows.Scan(&c.ID, &c.Name)
+		err := rows.Scan(&c.ID, &c.Name, &c.TaxClassID)
 		if err != nil {
 			return nil, err
 		}
@@ -36,16 +36,16 @@ func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 }
 
 func (repo *CategoryRepository) Create(category *models.Category) error {
-	query := "INSERT INTO categories (name) VALUES ($1) RETURNING id"
-	err := repo.db.QueryRow(query, category.Name).Scan(&category.ID)
+	query := "INSERT INTO categories (name, tax_class_id) VALUES ($1, $2) RETURNING id"
+	err := repo.db.QueryRow(query, category.Name, category.TaxClassID).Scan(&category.ID)
 	return err
 }
 
 func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
-	query := "SELECT id, name FROM categories WHERE id = $1"
+	query := "SELECT id, name, tax_class_id FROM categories WHERE id = $1"
 
 	var c models.Category
-	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name)
+	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.TaxClassID)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("kategori tidak ditemukan")
 	}
@@ -57,8 +57,8 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 }
 
 func (repo *CategoryRepository) Update(category *models.Category) error {
-	query := "UPDATE categories SET name = $1 WHERE id = $2"
-	result, err := repo.db.Exec(query, category.Name, category.ID)
+	query := "UPDATE categories SET name = $1, tax_class_id = $2 WHERE id = $3"
+	result, err := repo.db.Exec(query, category.Name, category.TaxClassID, category.ID)
 	if err != nil {
 		return err
 	}
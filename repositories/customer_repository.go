@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+	"time"
+)
+
+// ErrCustomerNotFound - pelanggan dengan id / nomor telepon tersebut tidak ada
+var ErrCustomerNotFound = errors.New("pelanggan tidak ditemukan")
+
+// ErrCustomerIsDebtor - pelanggan terdaftar sebagai debitur kasbon sehingga tidak bisa dihapus
+var ErrCustomerIsDebtor = errors.New("pelanggan terdaftar sebagai debitur kasbon dan tidak bisa dihapus")
+
+// ErrCustomerHasPoints - member masih memiliki saldo poin sehingga tidak bisa dihapus
+var ErrCustomerHasPoints = errors.New("member masih memiliki saldo poin dan tidak bisa dihapus")
+
+// ErrCustomerHasPointHistory - member memiliki riwayat ledger poin yang harus tetap tercatat
+var ErrCustomerHasPointHistory = errors.New("member memiliki riwayat poin dan tidak bisa dihapus")
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+// GetAll - daftar pelanggan, search mencocokkan nama atau nomor telepon
+func (repo *CustomerRepository) GetAll(search string) ([]models.Customer, error) {
+	query := "SELECT id, name, phone, email, notes, created_at FROM customers"
+	var args []interface{}
+	if search != "" {
+		query += " WHERE name ILIKE $1 OR phone LIKE $1"
+		args = append(args, "%"+search+"%")
+	}
+	query += " ORDER BY name, id"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customers := make([]models.Customer, 0)
+	for rows.Next() {
+		var c models.Customer
+		err := rows.Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.Notes, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+
+	return customers, rows.Err()
+}
+
+func (repo *CustomerRepository) Create(customer *models.Customer) error {
+	query := "INSERT INTO customers (name, phone, email, notes) VALUES ($1, $2, $3, $4) RETURNING id, created_at"
+	return repo.db.QueryRow(query, customer.Name, customer.Phone, customer.Email, customer.Notes).Scan(&customer.ID, &customer.CreatedAt)
+}
+
+func (repo *CustomerRepository) GetByID(id int) (*models.Customer, error) {
+	return repo.getOne("SELECT id, name, phone, email, notes, created_at FROM customers WHERE id = $1", id)
+}
+
+// GetByPhone - phone harus sudah dinormalisasi
+func (repo *CustomerRepository) GetByPhone(phone string) (*models.Customer, error) {
+	return repo.getOne("SELECT id, name, phone, email, notes, created_at FROM customers WHERE phone = $1", phone)
+}
+
+func (repo *CustomerRepository) getOne(query string, arg interface{}) (*models.Customer, error) {
+	var c models.Customer
+	err := repo.db.QueryRow(query, arg).Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.Notes, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (repo *CustomerRepository) Update(customer *models.Customer) error {
+	query := "UPDATE customers SET name = $1, phone = $2, email = $3, notes = $4 WHERE id = $5 RETURNING created_at"
+	err := repo.db.QueryRow(query, customer.Name, customer.Phone, customer.Email, customer.Notes, customer.ID).Scan(&customer.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrCustomerNotFound
+	}
+	return err
+}
+
+// Delete - transaksi pelanggan tetap ada, customer_id-nya menjadi NULL. Pelanggan yang terdaftar
+// sebagai debitur kasbon ditolak karena piutang dan cicilannya harus tetap tercatat, begitu juga
+// member yang masih memiliki saldo poin atau riwayat ledger poin.
+func (repo *CustomerRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var lockedID int
+	err = tx.QueryRow("SELECT id FROM customers WHERE id = $1 FOR UPDATE", id).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return ErrCustomerNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var debtor bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM debtors WHERE customer_id = $1)", id).Scan(&debtor); err != nil {
+		return err
+	}
+	if debtor {
+		return ErrCustomerIsDebtor
+	}
+
+	// poin yang sudah kedaluwarsa dihanguskan dulu agar saldo yang dicek adalah saldo berlaku
+	if err := expireMemberPoints(tx, id, time.Now()); err != nil {
+		return err
+	}
+	balance, err := loyaltyBalance(tx, id)
+	if err != nil {
+		return err
+	}
+	if balance != 0 {
+		return ErrCustomerHasPoints
+	}
+	var history bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM loyalty_ledger WHERE customer_id = $1)", id).Scan(&history); err != nil {
+		return err
+	}
+	if history {
+		return ErrCustomerHasPointHistory
+	}
+
+	if _, err := tx.Exec("DELETE FROM customers WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
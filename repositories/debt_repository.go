@@ -0,0 +1,394 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"time"
+)
+
+// ErrDebtorNotFound - debitur dengan id tersebut tidak ada
+var ErrDebtorNotFound = errors.New("debitur tidak ditemukan")
+
+type DebtRepository struct {
+	db *sql.DB
+}
+
+func NewDebtRepository(db *sql.DB) *DebtRepository {
+	return &DebtRepository{db: db}
+}
+
+const debtorColumns = `d.id, d.customer_id, c.name, c.phone, d.credit_limit, d.active, d.created_at,
+	COALESCE((SELECT SUM(r.amount - r.paid_amount - r.credited_amount) FROM receivables r WHERE r.debtor_id = d.id AND r.status = 'open'), 0) AS outstanding`
+
+func scanDebtor(row interface{ Scan(...interface{}) error }) (*models.Debtor, error) {
+	var d models.Debtor
+	err := row.Scan(&d.ID, &d.CustomerID, &d.CustomerName, &d.Phone, &d.CreditLimit, &d.Active, &d.CreatedAt, &d.Outstanding)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetAll - daftar debitur, outstandingOnly hanya yang masih punya sisa piutang (terbesar lebih dulu)
+func (repo *DebtRepository) GetAll(outstandingOnly bool) ([]models.Debtor, error) {
+	query := "SELECT " + debtorColumns + " FROM debtors d INNER JOIN customers c ON d.customer_id = c.id"
+	if outstandingOnly {
+		query = "SELECT * FROM (" + query + ") x WHERE x.outstanding > 0 ORDER BY x.outstanding DESC, x.id"
+	} else {
+		query += " ORDER BY c.name, d.id"
+	}
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	debtors := make([]models.Debtor, 0)
+	for rows.Next() {
+		d, err := scanDebtor(rows)
+		if err != nil {
+			return nil, err
+		}
+		debtors = append(debtors, *d)
+	}
+
+	return debtors, rows.Err()
+}
+
+func (repo *DebtRepository) Create(req models.DebtorRequest) (*models.Debtor, error) {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	var id int
+	err := repo.db.QueryRow("INSERT INTO debtors (customer_id, credit_limit, active) VALUES ($1, $2, $3) RETURNING id",
+		req.CustomerID, req.CreditLimit, active).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+func (repo *DebtRepository) GetByID(id int) (*models.Debtor, error) {
+	d, err := scanDebtor(repo.db.QueryRow("SELECT "+debtorColumns+" FROM debtors d INNER JOIN customers c ON d.customer_id = c.id WHERE d.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrDebtorNotFound
+	}
+	return d, err
+}
+
+// GetByCustomerID - debitur milik pelanggan, nil bila pelanggan belum terdaftar sebagai debitur
+func (repo *DebtRepository) GetByCustomerID(customerID int) (*models.Debtor, error) {
+	d, err := scanDebtor(repo.db.QueryRow("SELECT "+debtorColumns+" FROM debtors d INNER JOIN customers c ON d.customer_id = c.id WHERE d.customer_id = $1", customerID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+// Update - ubah limit kredit dan status aktif, limit baru boleh di bawah sisa piutang (kasbon baru ditolak)
+func (repo *DebtRepository) Update(id int, req models.DebtorRequest) (*models.Debtor, error) {
+	result, err := repo.db.Exec("UPDATE debtors SET credit_limit = $1, active = COALESCE($2, active) WHERE id = $3", req.CreditLimit, req.Active, id)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrDebtorNotFound
+	}
+	return repo.GetByID(id)
+}
+
+// GetReceivables - piutang debitur, openOnly hanya yang belum lunas
+func (repo *DebtRepository) GetReceivables(debtorID int, openOnly bool) ([]models.Receivable, error) {
+	query := `SELECT r.id, r.debtor_id, r.transaction_id, t.invoice_number, r.amount, r.paid_amount, r.credited_amount, r.status, r.created_at
+		FROM receivables r INNER JOIN transactions t ON r.transaction_id = t.id
+		WHERE r.debtor_id = $1`
+	if openOnly {
+		query += fmt.Sprintf(" AND r.status = '%s'", models.ReceivableStatusOpen)
+	}
+	query += " ORDER BY r.created_at, r.id"
+
+	rows, err := repo.db.Query(query, debtorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receivables := make([]models.Receivable, 0)
+	for rows.Next() {
+		var r models.Receivable
+		err := rows.Scan(&r.ID, &r.DebtorID, &r.TransactionID, &r.InvoiceNumber, &r.Amount, &r.PaidAmount, &r.CreditedAmount, &r.Status, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		r.Outstanding = r.Amount - r.PaidAmount - r.CreditedAmount
+		receivables = append(receivables, r)
+	}
+
+	return receivables, rows.Err()
+}
+
+// CreateRepayment - catat cicilan dan alokasikan ke piutang terlama lebih dulu dalam satu transaksi database
+func (repo *DebtRepository) CreateRepayment(debtorID int, req models.DebtRepaymentRequest) (*models.DebtRepayment, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// cicilan tunai masuk ke laci shift yang sedang open, shift dikunci lebih dulu seperti checkout
+	shiftID, err := currentShiftID(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	err = tx.QueryRow("SELECT id FROM debtors WHERE id = $1 FOR UPDATE", debtorID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrDebtorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`SELECT id, amount - paid_amount - credited_amount FROM receivables
+		WHERE debtor_id = $1 AND status = $2 ORDER BY created_at, id FOR UPDATE`, debtorID, models.ReceivableStatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	type openReceivable struct{ id, outstanding int }
+	open := make([]openReceivable, 0)
+	outstanding := 0
+	for rows.Next() {
+		var r openReceivable
+		if err := rows.Scan(&r.id, &r.outstanding); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		open = append(open, r)
+		outstanding += r.outstanding
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if req.Amount > outstanding {
+		return nil, &models.ValidationError{Message: fmt.Sprintf("pembayaran %d melebihi sisa kasbon %d", req.Amount, outstanding)}
+	}
+
+	repayment := models.DebtRepayment{
+		DebtorID:    debtorID,
+		Amount:      req.Amount,
+		Method:      req.Method,
+		Note:        req.Note,
+		ShiftID:     shiftID,
+		Allocations: make([]models.DebtAllocation, 0),
+	}
+	err = tx.QueryRow("INSERT INTO debt_repayments (debtor_id, amount, method, note, shift_id) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		debtorID, req.Amount, req.Method, req.Note, repayment.ShiftID).Scan(&repayment.ID, &repayment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := req.Amount
+	for _, r := range open {
+		if remaining == 0 {
+			break
+		}
+		amount := r.outstanding
+		if amount > remaining {
+			amount = remaining
+		}
+		remaining -= amount
+
+		status := models.ReceivableStatusOpen
+		if amount == r.outstanding {
+			status = models.ReceivableStatusSettled
+		}
+		_, err = tx.Exec("UPDATE receivables SET paid_amount = paid_amount + $1, status = $2 WHERE id = $3", amount, status, r.id)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec("INSERT INTO debt_repayment_allocations (repayment_id, receivable_id, amount) VALUES ($1, $2, $3)", repayment.ID, r.id, amount)
+		if err != nil {
+			return nil, err
+		}
+		repayment.Allocations = append(repayment.Allocations, models.DebtAllocation{ReceivableID: r.id, Amount: amount})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &repayment, nil
+}
+
+// GetRepayments - riwayat cicilan debitur, terbaru lebih dulu
+func (repo *DebtRepository) GetRepayments(debtorID int) ([]models.DebtRepayment, error) {
+	rows, err := repo.db.Query("SELECT id, debtor_id, amount, method, note, shift_id, created_at FROM debt_repayments WHERE debtor_id = $1 ORDER BY id DESC", debtorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	repayments := make([]models.DebtRepayment, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var r models.DebtRepayment
+		if err := rows.Scan(&r.ID, &r.DebtorID, &r.Amount, &r.Method, &r.Note, &r.ShiftID, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		r.Allocations = make([]models.DebtAllocation, 0)
+		index[r.ID] = len(repayments)
+		repayments = append(repayments, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	allocationRows, err := repo.db.Query(`SELECT a.repayment_id, a.receivable_id, a.amount
+		FROM debt_repayment_allocations a INNER JOIN debt_repayments p ON a.repayment_id = p.id
+		WHERE p.debtor_id = $1 ORDER BY a.id`, debtorID)
+	if err != nil {
+		return nil, err
+	}
+	defer allocationRows.Close()
+
+	for allocationRows.Next() {
+		var repaymentID int
+		var a models.DebtAllocation
+		if err := allocationRows.Scan(&repaymentID, &a.ReceivableID, &a.Amount); err != nil {
+			return nil, err
+		}
+		r := &repayments[index[repaymentID]]
+		r.Allocations = append(r.Allocations, a)
+	}
+
+	return repayments, allocationRows.Err()
+}
+
+// GetAging - sisa piutang per debitur berdasarkan umur piutang per tanggal now: 0-30, 31-60 dan lebih dari 60 hari
+func (repo *DebtRepository) GetAging(now time.Time) (*models.DebtAgingReport, error) {
+	query := `
+		SELECT d.id, c.name,
+			COALESCE(SUM(r.amount - r.paid_amount - r.credited_amount) FILTER (WHERE r.created_at > $1::timestamp - INTERVAL '31 days'), 0),
+			COALESCE(SUM(r.amount - r.paid_amount - r.credited_amount) FILTER (WHERE r.created_at <= $1::timestamp - INTERVAL '31 days' AND r.created_at > $1::timestamp - INTERVAL '61 days'), 0),
+			COALESCE(SUM(r.amount - r.paid_amount - r.credited_amount) FILTER (WHERE r.created_at <= $1::timestamp - INTERVAL '61 days'), 0)
+		FROM receivables r
+		INNER JOIN debtors d ON r.debtor_id = d.id
+		INNER JOIN customers c ON d.customer_id = c.id
+		WHERE r.status = $2
+		GROUP BY d.id, c.name
+		ORDER BY c.name, d.id
+	`
+	rows, err := repo.db.Query(query, now, models.ReceivableStatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := models.DebtAgingReport{Debtors: make([]models.DebtAging, 0)}
+	for rows.Next() {
+		var a models.DebtAging
+		if err := rows.Scan(&a.DebtorID, &a.CustomerName, &a.Days0To30, &a.Days31To60, &a.Over60); err != nil {
+			return nil, err
+		}
+		a.Total = a.Days0To30 + a.Days31To60 + a.Over60
+		report.Totals.Days0To30 += a.Days0To30
+		report.Totals.Days31To60 += a.Days31To60
+		report.Totals.Over60 += a.Over60
+		report.Totals.Total += a.Total
+		report.Debtors = append(report.Debtors, a)
+	}
+
+	return &report, rows.Err()
+}
+
+// insertReceivable - catat piutang checkout kasbon. Debitur dikunci agar dua checkout bersamaan
+// tidak bisa melewati limit kredit.
+func insertReceivable(tx *sql.Tx, trx *models.Transaction) error {
+	r := trx.Receivable
+	if r == nil {
+		return nil
+	}
+
+	var creditLimit int
+	var active bool
+	err := tx.QueryRow("SELECT credit_limit, active FROM debtors WHERE id = $1 FOR UPDATE", r.DebtorID).Scan(&creditLimit, &active)
+	if err == sql.ErrNoRows {
+		return &models.ValidationError{Message: fmt.Sprintf("debitur %d tidak ditemukan", r.DebtorID)}
+	}
+	if err != nil {
+		return err
+	}
+	if !active {
+		return &models.ValidationError{Message: "debitur tidak aktif, kasbon tidak diizinkan"}
+	}
+
+	var outstanding int
+	err = tx.QueryRow("SELECT COALESCE(SUM(amount - paid_amount - credited_amount), 0) FROM receivables WHERE debtor_id = $1 AND status = $2",
+		r.DebtorID, models.ReceivableStatusOpen).Scan(&outstanding)
+	if err != nil {
+		return err
+	}
+	if outstanding+r.Amount > creditLimit {
+		return &models.ValidationError{Message: fmt.Sprintf("limit kasbon terlampaui: limit %d, sisa kasbon %d, kasbon baru %d", creditLimit, outstanding, r.Amount)}
+	}
+
+	r.TransactionID = trx.ID
+	r.InvoiceNumber = trx.InvoiceNumber
+	r.Status = models.ReceivableStatusOpen
+	r.Outstanding = r.Amount
+	return tx.QueryRow("INSERT INTO receivables (debtor_id, transaction_id, amount, status) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		r.DebtorID, trx.ID, r.Amount, r.Status).Scan(&r.ID, &r.CreatedAt)
+}
+
+// creditReceivable - nominal refund / void transaksi kasbon lebih dulu mengurangi sisa piutangnya,
+// mengembalikan nominal yang dipotong dari piutang
+func creditReceivable(tx *sql.Tx, transactionID, amount int) (int, error) {
+	var debtorID, receivableID, outstanding int
+	err := tx.QueryRow("SELECT debtor_id FROM receivables WHERE transaction_id = $1", transactionID).Scan(&debtorID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// kunci debitur dulu, urutan yang sama dengan checkout dan cicilan
+	if _, err := tx.Exec("SELECT id FROM debtors WHERE id = $1 FOR UPDATE", debtorID); err != nil {
+		return 0, err
+	}
+	err = tx.QueryRow("SELECT id, amount - paid_amount - credited_amount FROM receivables WHERE transaction_id = $1 FOR UPDATE", transactionID).
+		Scan(&receivableID, &outstanding)
+	if err != nil {
+		return 0, err
+	}
+
+	credit := amount
+	if credit > outstanding {
+		credit = outstanding
+	}
+	if credit <= 0 {
+		return 0, nil
+	}
+
+	status := models.ReceivableStatusOpen
+	if credit == outstanding {
+		status = models.ReceivableStatusSettled
+	}
+	_, err = tx.Exec("UPDATE receivables SET credited_amount = credited_amount + $1, status = $2 WHERE id = $3", credit, status, receivableID)
+	if err != nil {
+		return 0, err
+	}
+	return credit, nil
+}
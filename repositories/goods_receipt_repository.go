@@ -0,0 +1,408 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+)
+
+// ErrGoodsReceiptNotFound - penerimaan barang dengan id tersebut tidak ada
+var ErrGoodsReceiptNotFound = errors.New("penerimaan barang tidak ditemukan")
+
+// ErrGoodsReceiptReversed - penerimaan barang sudah dibatalkan sebelumnya
+var ErrGoodsReceiptReversed = errors.New("penerimaan barang sudah dibatalkan")
+
+type GoodsReceiptRepository struct {
+	db *sql.DB
+}
+
+func NewGoodsReceiptRepository(db *sql.DB) *GoodsReceiptRepository {
+	return &GoodsReceiptRepository{db: db}
+}
+
+const goodsReceiptColumns = `g.id, g.supplier_id, g.supplier_name, g.invoice_ref, g.purchase_order_id, g.status, g.note, g.total_amount,
+	g.user_id, u.username, g.created_at, g.reversed_by, g.reversed_at, g.reverse_reason`
+
+func scanGoodsReceipt(row interface{ Scan(...interface{}) error }) (*models.GoodsReceipt, error) {
+	var g models.GoodsReceipt
+	err := row.Scan(&g.ID, &g.SupplierID, &g.SupplierName, &g.InvoiceRef, &g.PurchaseOrderID, &g.Status, &g.Note, &g.TotalAmount,
+		&g.UserID, &g.Username, &g.CreatedAt, &g.ReversedBy, &g.ReversedAt, &g.ReverseReason)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetAll - daftar penerimaan barang terbaru lebih dulu tanpa baris
+func (repo *GoodsReceiptRepository) GetAll(filter models.GoodsReceiptFilter) ([]models.GoodsReceipt, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	if filter.StartDate != nil {
+		args = append(args, *filter.StartDate)
+		conditions = append(conditions, fmt.Sprintf("g.created_at >= $%d", len(args)))
+	}
+	if filter.EndDate != nil {
+		args = append(args, *filter.EndDate)
+		conditions = append(conditions, fmt.Sprintf("g.created_at < $%d", len(args)))
+	}
+	if filter.SupplierID != nil {
+		args = append(args, *filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("g.supplier_id = $%d", len(args)))
+	}
+	if filter.Supplier != "" {
+		args = append(args, "%"+filter.Supplier+"%")
+		conditions = append(conditions, fmt.Sprintf("g.supplier_name ILIKE $%d", len(args)))
+	}
+	if filter.PurchaseOrderID != nil {
+		args = append(args, *filter.PurchaseOrderID)
+		conditions = append(conditions, fmt.Sprintf("g.purchase_order_id = $%d", len(args)))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("g.status = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := repo.db.Query(`SELECT `+goodsReceiptColumns+`
+		FROM goods_receipts g LEFT JOIN users u ON g.user_id = u.id`+where+` ORDER BY g.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := make([]models.GoodsReceipt, 0)
+	for rows.Next() {
+		g, err := scanGoodsReceipt(rows)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, *g)
+	}
+	return receipts, rows.Err()
+}
+
+func (repo *GoodsReceiptRepository) GetByID(id int) (*models.GoodsReceipt, error) {
+	g, err := scanGoodsReceipt(repo.db.QueryRow(`SELECT `+goodsReceiptColumns+`
+		FROM goods_receipts g LEFT JOIN users u ON g.user_id = u.id WHERE g.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrGoodsReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if g.Items, err = getGoodsReceiptItems(repo.db, id); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Create - posting penerimaan barang secara atomik: stok bertambah lewat movement receipt dan harga pokok
+// setiap produk diperbarui rata-rata bergerak. Bila terhubung ke purchase order, received_qty dan status
+// purchase order ikut diperbarui dan quantity tidak boleh melebihi sisa yang belum diterima.
+func (repo *GoodsReceiptRepository) Create(req models.GoodsReceiptRequest) (*models.GoodsReceipt, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// sisa per produk yang belum diterima, hanya untuk penerimaan dari purchase order
+	var remaining map[int]int
+	if req.PurchaseOrderID != nil {
+		status, err := lockPurchaseOrder(tx, *req.PurchaseOrderID)
+		if err != nil {
+			return nil, err
+		}
+		if status != models.PurchaseOrderStatusSent && status != models.PurchaseOrderStatusPartiallyReceived {
+			return nil, ErrPurchaseOrderStatus
+		}
+
+		lines, err := getPurchaseOrderItems(tx, *req.PurchaseOrderID)
+		if err != nil {
+			return nil, err
+		}
+		remaining = make(map[int]int)
+		for _, line := range lines {
+			remaining[line.ProductID] = line.Quantity - line.ReceivedQty
+		}
+	}
+
+	productIDs := make([]int, 0, len(req.Items))
+	for _, item := range req.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := lockStockProducts(tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	itemErrors := make([]models.ItemError, 0)
+	total := 0
+	for i, item := range req.Items {
+		total += item.Quantity * item.UnitCost
+		if _, ok := products[item.ProductID]; !ok {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "product_id", Reason: fmt.Sprintf("produk %d tidak ditemukan", item.ProductID)})
+			continue
+		}
+		if remaining == nil {
+			continue
+		}
+		left, ok := remaining[item.ProductID]
+		if !ok {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "product_id", Reason: fmt.Sprintf("produk %d tidak ada di purchase order", item.ProductID)})
+			continue
+		}
+		if item.Quantity > left {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: fmt.Sprintf("melebihi sisa yang belum diterima, sisa %d", left)})
+		}
+		remaining[item.ProductID] = left - item.Quantity
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "penerimaan barang tidak valid", Items: itemErrors}
+	}
+
+	var invoiceRef, note *string
+	if req.InvoiceRef != "" {
+		invoiceRef = &req.InvoiceRef
+	}
+	if req.Note != "" {
+		note = &req.Note
+	}
+	var id int
+	err = tx.QueryRow(`INSERT INTO goods_receipts (supplier_id, supplier_name, invoice_ref, purchase_order_id, status, note, total_amount, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		req.SupplierID, req.SupplierName, invoiceRef, req.PurchaseOrderID, models.GoodsReceiptStatusPosted, note, total, req.UserID).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		p := products[item.ProductID]
+		costAfter := movingAverageCost(p.Stock, p.CostPrice, item.Quantity, item.UnitCost)
+
+		movement := models.StockMovement{
+			ProductID:     item.ProductID,
+			Type:          models.StockMovementReceipt,
+			Quantity:      item.Quantity,
+			ReferenceType: models.StockReferenceReceipt,
+			ReferenceID:   &id,
+			UserID:        req.UserID,
+		}
+		if err := recordStockMovement(tx, &movement); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE products SET cost_price = $1 WHERE id = $2", costAfter, item.ProductID); err != nil {
+			return nil, err
+		}
+
+		_, err := tx.Exec(`INSERT INTO goods_receipt_items (receipt_id, product_id, product_name, quantity, unit_cost, cost_before, cost_after, stock_movement_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			id, item.ProductID, p.Name, item.Quantity, item.UnitCost, p.CostPrice, costAfter, movement.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if req.PurchaseOrderID != nil {
+			_, err := tx.Exec("UPDATE purchase_order_items SET received_qty = received_qty + $1 WHERE purchase_order_id = $2 AND product_id = $3",
+				item.Quantity, *req.PurchaseOrderID, item.ProductID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// satu produk boleh muncul di beberapa baris, baris berikutnya memakai saldo dan harga pokok terbaru
+		p.Stock = movement.BalanceAfter
+		p.CostPrice = costAfter
+		products[item.ProductID] = p
+	}
+
+	if req.PurchaseOrderID != nil {
+		if err := refreshPurchaseOrderStatus(tx, *req.PurchaseOrderID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Reverse - batalkan penerimaan yang sudah di-posting: stok dikurangi kembali, harga pokok dihitung mundur
+// dan received_qty purchase order dikembalikan. Ditolak bila stok sudah tidak cukup (barang sudah terjual).
+// Baris untuk produk yang sudah dihapus dilewati tanpa movement.
+func (repo *GoodsReceiptRepository) Reverse(id int, req models.ReverseGoodsReceiptRequest) (*models.GoodsReceipt, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var purchaseOrderID *int
+	err = tx.QueryRow("SELECT status, purchase_order_id FROM goods_receipts WHERE id = $1 FOR UPDATE", id).Scan(&status, &purchaseOrderID)
+	if err == sql.ErrNoRows {
+		return nil, ErrGoodsReceiptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != models.GoodsReceiptStatusPosted {
+		return nil, ErrGoodsReceiptReversed
+	}
+
+	var purchaseOrderStatus string
+	if purchaseOrderID != nil {
+		if purchaseOrderStatus, err = lockPurchaseOrder(tx, *purchaseOrderID); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := getGoodsReceiptItems(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	productIDs := make([]int, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := lockStockProducts(tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[int]int)
+	for productID, p := range products {
+		balances[productID] = p.Stock
+	}
+	itemErrors := make([]models.ItemError, 0)
+	for i, item := range items {
+		if _, ok := products[item.ProductID]; !ok {
+			continue
+		}
+		balances[item.ProductID] -= item.Quantity
+		if balances[item.ProductID] < 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: fmt.Sprintf("stok tidak cukup untuk dibatalkan, tersedia %d", balances[item.ProductID]+item.Quantity)})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "penerimaan barang tidak bisa dibatalkan", Items: itemErrors}
+	}
+
+	note := "pembatalan penerimaan barang"
+	if req.Reason != "" {
+		note += " - " + req.Reason
+	}
+	for _, item := range items {
+		if purchaseOrderID != nil {
+			_, err := tx.Exec("UPDATE purchase_order_items SET received_qty = received_qty - $1 WHERE purchase_order_id = $2 AND product_id = $3",
+				item.Quantity, *purchaseOrderID, item.ProductID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		p, ok := products[item.ProductID]
+		if !ok {
+			continue
+		}
+		costAfter := reverseMovingAverageCost(p.Stock, p.CostPrice, item.Quantity, item.UnitCost, item.CostBefore)
+
+		movement := models.StockMovement{
+			ProductID:     item.ProductID,
+			Type:          models.StockMovementReceipt,
+			Quantity:      -item.Quantity,
+			ReferenceType: models.StockReferenceReceipt,
+			ReferenceID:   &id,
+			Note:          &note,
+			UserID:        req.UserID,
+		}
+		if err := recordStockMovement(tx, &movement); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE products SET cost_price = $1 WHERE id = $2", costAfter, item.ProductID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("UPDATE goods_receipt_items SET reversal_movement_id = $1 WHERE id = $2", movement.ID, item.ID); err != nil {
+			return nil, err
+		}
+
+		p.Stock = movement.BalanceAfter
+		p.CostPrice = costAfter
+		products[item.ProductID] = p
+	}
+
+	// purchase order yang sudah dibatalkan tetap cancelled
+	if purchaseOrderID != nil && purchaseOrderStatus != models.PurchaseOrderStatusCancelled {
+		if err := refreshPurchaseOrderStatus(tx, *purchaseOrderID); err != nil {
+			return nil, err
+		}
+	}
+
+	var reason *string
+	if req.Reason != "" {
+		reason = &req.Reason
+	}
+	_, err = tx.Exec(`UPDATE goods_receipts SET status = $1, reversed_by = $2, reversed_at = CURRENT_TIMESTAMP, reverse_reason = $3
+		WHERE id = $4`, models.GoodsReceiptStatusReversed, req.UserID, reason, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+func getGoodsReceiptItems(q queryer, receiptID int) ([]models.GoodsReceiptItem, error) {
+	rows, err := q.Query(`SELECT id, product_id, product_name, quantity, unit_cost, cost_before, cost_after, stock_movement_id, reversal_movement_id
+		FROM goods_receipt_items WHERE receipt_id = $1 ORDER BY id`, receiptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.GoodsReceiptItem, 0)
+	for rows.Next() {
+		var item models.GoodsReceiptItem
+		err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitCost,
+			&item.CostBefore, &item.CostAfter, &item.StockMovementID, &item.ReversalMovementID)
+		if err != nil {
+			return nil, err
+		}
+		item.Subtotal = item.Quantity * item.UnitCost
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// movingAverageCost - harga pokok baru setelah menerima quantity unit seharga unitCost, dibulatkan ke rupiah terdekat.
+// Stok yang kosong / minus atau harga pokok yang belum pernah diisi (0) langsung memakai unitCost.
+func movingAverageCost(stock, cost, quantity, unitCost int) int {
+	if stock <= 0 || cost == 0 {
+		return unitCost
+	}
+	total := stock + quantity
+	return (stock*cost + quantity*unitCost + total/2) / total
+}
+
+// reverseMovingAverageCost - kebalikan movingAverageCost saat penerimaan dibatalkan. Bila stok habis
+// atau hasilnya tidak masuk akal, harga pokok kembali ke nilai sebelum penerimaan (costBefore).
+func reverseMovingAverageCost(stock, cost, quantity, unitCost, costBefore int) int {
+	left := stock - quantity
+	if left <= 0 {
+		return costBefore
+	}
+	value := stock*cost - quantity*unitCost
+	if value < 0 {
+		return costBefore
+	}
+	return (value + left/2) / left
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+	"time"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve - daftarkan key baru milik userID dengan status processing. Bila key pengguna tersebut sudah ada,
+// kembalikan record yang tersimpan dan reserved = false.
+func (repo *IdempotencyRepository) Reserve(userID *int, key, requestHash string) (*models.IdempotencyRecord, bool, error) {
+	result, err := repo.db.Exec(`INSERT INTO idempotency_keys (user_id, key, request_hash, status) VALUES ($1, $2, $3, $4)
+		ON CONFLICT ((COALESCE(user_id, 0)), key) DO NOTHING`,
+		userID, key, requestHash, models.IdempotencyStatusProcessing)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+	if rows == 1 {
+		return nil, true, nil
+	}
+
+	var record models.IdempotencyRecord
+	var responseStatus sql.NullInt64
+	err = repo.db.QueryRow(`SELECT user_id, key, request_hash, status, response_status, response_body, transaction_id, created_at
+		FROM idempotency_keys WHERE key = $1 AND user_id IS NOT DISTINCT FROM $2`, key, userID).
+		Scan(&record.UserID, &record.Key, &record.RequestHash, &record.Status, &responseStatus, &record.ResponseBody, &record.TransactionID, &record.CreatedAt)
+	if err == sql.ErrNoRows {
+		// key baru saja dihapus (request sebelumnya gagal), coba daftarkan ulang
+		return repo.Reserve(userID, key, requestHash)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	record.ResponseStatus = int(responseStatus.Int64)
+
+	return &record, false, nil
+}
+
+// Complete - simpan response checkout untuk diputar ulang pada request berikutnya dengan key yang sama
+func (repo *IdempotencyRepository) Complete(userID *int, key string, responseStatus int, responseBody []byte, transactionID *int) error {
+	_, err := repo.db.Exec(`UPDATE idempotency_keys SET status = $1, response_status = $2, response_body = $3, transaction_id = COALESCE($4, transaction_id)
+		WHERE key = $5 AND user_id IS NOT DISTINCT FROM $6`,
+		models.IdempotencyStatusCompleted, responseStatus, responseBody, transactionID, key, userID)
+	return err
+}
+
+// Release - hapus key yang request-nya gagal agar client bisa mencoba lagi
+func (repo *IdempotencyRepository) Release(userID *int, key string) error {
+	_, err := repo.db.Exec("DELETE FROM idempotency_keys WHERE key = $1 AND user_id IS NOT DISTINCT FROM $2 AND status = $3",
+		key, userID, models.IdempotencyStatusProcessing)
+	return err
+}
+
+// completeIdempotencyKey - tandai key selesai dengan transaksi yang baru dibuat, dipanggil di dalam transaksi
+// database checkout sehingga key tidak pernah tertinggal processing setelah transaksinya tersimpan
+func completeIdempotencyKey(tx *sql.Tx, userID *int, key string, transactionID int) error {
+	_, err := tx.Exec("UPDATE idempotency_keys SET status = $1, transaction_id = $2 WHERE key = $3 AND user_id IS NOT DISTINCT FROM $4",
+		models.IdempotencyStatusCompleted, transactionID, key, userID)
+	return err
+}
+
+// DeleteExpired - hapus key selesai yang lebih lama dari completedBefore dan key processing
+// yang tertinggal (misalnya server mati di tengah request) sebelum processingBefore
+func (repo *IdempotencyRepository) DeleteExpired(completedBefore, processingBefore time.Time) (int64, error) {
+	result, err := repo.db.Exec(`DELETE FROM idempotency_keys
+		WHERE (status = $1 AND created_at < $2) OR (status = $3 AND created_at < $4)`,
+		models.IdempotencyStatusCompleted, completedBefore, models.IdempotencyStatusProcessing, processingBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
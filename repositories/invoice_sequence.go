@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Periode reset nomor urut invoice
+const (
+	InvoiceResetDaily   = "daily"
+	InvoiceResetMonthly = "monthly"
+	InvoiceResetYearly  = "yearly"
+	InvoiceResetNever   = "never"
+)
+
+// InvoiceSequence - format dan periode reset nomor invoice, misalnya INV/OUTLET/2026/10/000123.
+// Reset harian menambahkan tanggal ke nomor agar tetap unik dalam satu bulan.
+type InvoiceSequence struct {
+	OutletCode  string
+	ResetPeriod string
+}
+
+// ValidInvoiceResetPeriod - cek nilai konfigurasi periode reset
+func ValidInvoiceResetPeriod(period string) bool {
+	switch period {
+	case InvoiceResetDaily, InvoiceResetMonthly, InvoiceResetYearly, InvoiceResetNever:
+		return true
+	}
+	return false
+}
+
+// periodKey - kunci counter untuk periode yang memuat t
+func (s InvoiceSequence) periodKey(t time.Time) string {
+	switch s.ResetPeriod {
+	case InvoiceResetDaily:
+		return t.Format("2006-01-02")
+	case InvoiceResetYearly:
+		return t.Format("2006")
+	case InvoiceResetNever:
+		return "all"
+	default:
+		return t.Format("2006-01")
+	}
+}
+
+func (s InvoiceSequence) format(t time.Time, number int) string {
+	if s.ResetPeriod == InvoiceResetDaily {
+		return fmt.Sprintf("INV/%s/%s/%06d", s.OutletCode, t.Format("2006/01/02"), number)
+	}
+	return fmt.Sprintf("INV/%s/%s/%06d", s.OutletCode, t.Format("2006/01"), number)
+}
+
+// Next - ambil nomor invoice berikutnya di dalam transaksi checkout. Baris counter terkunci
+// sampai commit dan kenaikannya ikut di-rollback bila checkout gagal, sehingga nomor tidak pernah loncat.
+func (s InvoiceSequence) Next(tx *sql.Tx, t time.Time) (string, error) {
+	var number int
+	err := tx.QueryRow(`INSERT INTO invoice_counters (outlet_code, period_key, last_number) VALUES ($1, $2, 1)
+		ON CONFLICT (outlet_code, period_key) DO UPDATE SET last_number = invoice_counters.last_number + 1
+		RETURNING last_number`, s.OutletCode, s.periodKey(t)).Scan(&number)
+	if err != nil {
+		return "", err
+	}
+	return s.format(t, number), nil
+}
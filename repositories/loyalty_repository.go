@@ -0,0 +1,318 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+	"time"
+)
+
+type LoyaltyRepository struct {
+	db *sql.DB
+}
+
+func NewLoyaltyRepository(db *sql.DB) *LoyaltyRepository {
+	return &LoyaltyRepository{db: db}
+}
+
+// GetBalance - saldo poin member setelah poin yang kedaluwarsa dihanguskan
+func (repo *LoyaltyRepository) GetBalance(customerID int, now time.Time) (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := lockLoyaltyMember(tx, customerID); err != nil {
+		return 0, err
+	}
+	if err := expireMemberPoints(tx, customerID, now); err != nil {
+		return 0, err
+	}
+	balance, err := loyaltyBalance(tx, customerID)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, tx.Commit()
+}
+
+// GetHistory - entri ledger poin member, terbaru lebih dulu
+func (repo *LoyaltyRepository) GetHistory(customerID, limit int) ([]models.LoyaltyEntry, error) {
+	rows, err := repo.db.Query(`SELECT id, customer_id, transaction_id, refund_id, type, points, balance_after, expires_at, created_at
+		FROM loyalty_ledger WHERE customer_id = $1 ORDER BY id DESC LIMIT $2`, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.LoyaltyEntry, 0)
+	for rows.Next() {
+		var e models.LoyaltyEntry
+		err := rows.Scan(&e.ID, &e.CustomerID, &e.TransactionID, &e.RefundID, &e.Type, &e.Points, &e.BalanceAfter, &e.ExpiresAt, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// ExpireDue - hanguskan poin member yang batch earn-nya kedaluwarsa di rentang (since, now]
+func (repo *LoyaltyRepository) ExpireDue(since, now time.Time) (int, error) {
+	rows, err := repo.db.Query("SELECT DISTINCT customer_id FROM loyalty_ledger WHERE type = $1 AND expires_at > $2 AND expires_at <= $3",
+		models.LoyaltyEntryEarn, since, now)
+	if err != nil {
+		return 0, err
+	}
+	customerIDs := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		customerIDs = append(customerIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// satu transaksi database per member agar lock pelanggan tidak ditahan lama
+	for _, id := range customerIDs {
+		if err := repo.expireMember(id, now); err != nil {
+			return 0, err
+		}
+	}
+	return len(customerIDs), nil
+}
+
+func (repo *LoyaltyRepository) expireMember(customerID int, now time.Time) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockLoyaltyMember(tx, customerID); err != nil {
+		return err
+	}
+	if err := expireMemberPoints(tx, customerID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockLoyaltyMember - kunci baris pelanggan agar ledger poin tidak ditulis bersamaan.
+// Di checkout dan refund lock ini diambil setelah lock produk, urutan yang sama di semua alur.
+func lockLoyaltyMember(tx *sql.Tx, customerID int) error {
+	var id int
+	err := tx.QueryRow("SELECT id FROM customers WHERE id = $1 FOR UPDATE", customerID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrCustomerNotFound
+	}
+	return err
+}
+
+func loyaltyBalance(tx *sql.Tx, customerID int) (int, error) {
+	var balance int
+	err := tx.QueryRow("SELECT balance_after FROM loyalty_ledger WHERE customer_id = $1 ORDER BY id DESC LIMIT 1", customerID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return balance, err
+}
+
+// insertLoyaltyEntry - tulis entri ledger dan hitung balance_after, member harus sudah dikunci
+func insertLoyaltyEntry(tx *sql.Tx, e *models.LoyaltyEntry) error {
+	balance, err := loyaltyBalance(tx, e.CustomerID)
+	if err != nil {
+		return err
+	}
+	e.BalanceAfter = balance + e.Points
+
+	return tx.QueryRow(`INSERT INTO loyalty_ledger (customer_id, transaction_id, refund_id, type, points, balance_after, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`,
+		e.CustomerID, e.TransactionID, e.RefundID, e.Type, e.Points, e.BalanceAfter, e.ExpiresAt).Scan(&e.ID, &e.CreatedAt)
+}
+
+// expireMemberPoints - hanguskan poin secara FIFO: poin yang dipakai dianggap mengambil batch earn
+// paling lama lebih dulu, sehingga yang hangus adalah sisa batch kedaluwarsa yang belum terpakai
+func expireMemberPoints(tx *sql.Tx, customerID int, now time.Time) error {
+	var expiredEarned, redeemed, alreadyExpired int
+	err := tx.QueryRow(`
+		SELECT COALESCE(SUM(points) FILTER (WHERE type IN ('earn', 'earn_reversal') AND expires_at <= $2), 0),
+			COALESCE(-SUM(points) FILTER (WHERE type IN ('redeem', 'redeem_reversal')), 0),
+			COALESCE(-SUM(points) FILTER (WHERE type = 'expire'), 0)
+		FROM loyalty_ledger
+		WHERE customer_id = $1
+	`, customerID, now).Scan(&expiredEarned, &redeemed, &alreadyExpired)
+	if err != nil {
+		return err
+	}
+
+	points := expiredEarned - redeemed - alreadyExpired
+	balance, err := loyaltyBalance(tx, customerID)
+	if err != nil {
+		return err
+	}
+	if points > balance {
+		points = balance
+	}
+	if points <= 0 {
+		return nil
+	}
+
+	return insertLoyaltyEntry(tx, &models.LoyaltyEntry{CustomerID: customerID, Type: models.LoyaltyEntryExpire, Points: -points})
+}
+
+// redeemPoints - potong saldo poin yang dipakai sebagai pembayaran di checkout
+func redeemPoints(tx *sql.Tx, trx *models.Transaction) error {
+	if trx.CustomerID == nil || trx.PointsRedeemed == 0 {
+		return nil
+	}
+
+	if err := lockLoyaltyMember(tx, *trx.CustomerID); err != nil {
+		return err
+	}
+	if err := expireMemberPoints(tx, *trx.CustomerID, time.Now()); err != nil {
+		return err
+	}
+	balance, err := loyaltyBalance(tx, *trx.CustomerID)
+	if err != nil {
+		return err
+	}
+	if trx.PointsRedeemed > balance {
+		return &models.ValidationError{Message: fmt.Sprintf("poin tidak mencukupi: saldo %d, dipakai %d", balance, trx.PointsRedeemed)}
+	}
+
+	return insertLoyaltyEntry(tx, &models.LoyaltyEntry{
+		CustomerID:    *trx.CustomerID,
+		TransactionID: &trx.ID,
+		Type:          models.LoyaltyEntryRedeem,
+		Points:        -trx.PointsRedeemed,
+	})
+}
+
+// awardTransactionPoints - catat poin yang didapat dari transaksi yang sudah lunas, aman dipanggil ulang
+func awardTransactionPoints(tx *sql.Tx, transactionID int) error {
+	var customerID *int
+	var points int
+	var expiresAt *time.Time
+	var status string
+	err := tx.QueryRow("SELECT customer_id, points_earned, points_expire_at, status FROM transactions WHERE id = $1", transactionID).
+		Scan(&customerID, &points, &expiresAt, &status)
+	if err != nil {
+		return err
+	}
+	if customerID == nil || points == 0 || status != models.TransactionStatusCompleted {
+		return nil
+	}
+
+	if err := lockLoyaltyMember(tx, *customerID); err != nil {
+		return err
+	}
+	var awarded bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM loyalty_ledger WHERE transaction_id = $1 AND type = $2)", transactionID, models.LoyaltyEntryEarn).Scan(&awarded)
+	if err != nil || awarded {
+		return err
+	}
+
+	return insertLoyaltyEntry(tx, &models.LoyaltyEntry{
+		CustomerID:    *customerID,
+		TransactionID: &transactionID,
+		Type:          models.LoyaltyEntryEarn,
+		Points:        points,
+		ExpiresAt:     expiresAt,
+	})
+}
+
+// reverseTransactionPoints - sesuaikan poin setelah refund / void / pembayaran kedaluwarsa.
+// Poin yang didapat dikurangi proporsional terhadap nominal yang di-refund. Poin yang dipakai sebagai
+// pembayaran dikembalikan sebesar bagian tender poin pada refund (refund_payments), atau seluruhnya
+// bila transaksi kedaluwarsa (refundID nil). Pengurangan tidak pernah membuat saldo negatif.
+func reverseTransactionPoints(tx *sql.Tx, transactionID int, refundID *int, fullReversal bool) error {
+	var customerID *int
+	var pointsEarned, totalAmount, refunded int
+	err := tx.QueryRow(`SELECT customer_id, points_earned, total_amount,
+			(SELECT COALESCE(SUM(total_amount), 0) FROM refunds WHERE transaction_id = t.id)
+		FROM transactions t WHERE id = $1`, transactionID).Scan(&customerID, &pointsEarned, &totalAmount, &refunded)
+	if err != nil {
+		return err
+	}
+	if customerID == nil {
+		return nil
+	}
+
+	if err := lockLoyaltyMember(tx, *customerID); err != nil {
+		return err
+	}
+
+	var earnedNet, redeemedNet int
+	var earnExpiresAt *time.Time
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(points) FILTER (WHERE type IN ('earn', 'earn_reversal')), 0),
+			COALESCE(SUM(points) FILTER (WHERE type IN ('redeem', 'redeem_reversal')), 0),
+			MAX(expires_at) FILTER (WHERE type = 'earn')
+		FROM loyalty_ledger
+		WHERE transaction_id = $1
+	`, transactionID).Scan(&earnedNet, &redeemedNet, &earnExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	// poin earn hanya dibalik bila sudah pernah dicatat (transaksi pending belum mendapat poin)
+	if earnExpiresAt != nil {
+		target := 0
+		if !fullReversal && totalAmount > 0 && refunded < totalAmount {
+			target = pointsEarned * (totalAmount - refunded) / totalAmount
+		}
+		if delta := target - earnedNet; delta < 0 {
+			balance, err := loyaltyBalance(tx, *customerID)
+			if err != nil {
+				return err
+			}
+			if -delta > balance {
+				delta = -balance
+			}
+			if delta < 0 {
+				err := insertLoyaltyEntry(tx, &models.LoyaltyEntry{
+					CustomerID:    *customerID,
+					TransactionID: &transactionID,
+					RefundID:      refundID,
+					Type:          models.LoyaltyEntryEarnReversal,
+					Points:        delta,
+					ExpiresAt:     earnExpiresAt,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	returned := -redeemedNet
+	if refundID != nil {
+		err := tx.QueryRow("SELECT COALESCE(SUM(points), 0) FROM refund_payments WHERE refund_id = $1 AND method = $2",
+			*refundID, models.PaymentMethodPoints).Scan(&returned)
+		if err != nil {
+			return err
+		}
+		if returned > -redeemedNet {
+			returned = -redeemedNet
+		}
+	}
+	if returned > 0 {
+		return insertLoyaltyEntry(tx, &models.LoyaltyEntry{
+			CustomerID:    *customerID,
+			TransactionID: &transactionID,
+			RefundID:      refundID,
+			Type:          models.LoyaltyEntryRedeemReversal,
+			Points:        returned,
+		})
+	}
+
+	return nil
+}
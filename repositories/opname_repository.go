@@ -0,0 +1,444 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrOpnameNotFound - sesi stock opname dengan id tersebut tidak ada
+var ErrOpnameNotFound = errors.New("sesi stock opname tidak ditemukan")
+
+// ErrOpnameAlreadyOpen - masih ada sesi stock opname yang belum di-approve / dibatalkan
+var ErrOpnameAlreadyOpen = errors.New("masih ada sesi stock opname yang open")
+
+// ErrOpnameClosed - sesi sudah di-approve atau dibatalkan sehingga tidak bisa diubah
+var ErrOpnameClosed = errors.New("sesi stock opname sudah ditutup")
+
+type OpnameRepository struct {
+	db *sql.DB
+}
+
+func NewOpnameRepository(db *sql.DB) *OpnameRepository {
+	return &OpnameRepository{db: db}
+}
+
+const opnameColumns = "id, status, category_ids, note, created_by, created_at, approved_by, approved_at, cancelled_at"
+
+func scanOpname(row interface{ Scan(...interface{}) error }) (*models.StockOpname, error) {
+	var o models.StockOpname
+	err := row.Scan(&o.ID, &o.Status, (*pq.Int64Array)(&o.CategoryIDs), &o.Note, &o.CreatedBy, &o.CreatedAt, &o.ApprovedBy, &o.ApprovedAt, &o.CancelledAt)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Create - buka sesi dan snapshot stok produk pada kategori terpilih (atau seluruh produk).
+// Produk dikunci FOR SHARE lebih dulu sehingga tidak ada checkout yang setengah jalan saat snapshot dibaca.
+func (repo *OpnameRepository) Create(req models.StockOpnameRequest) (*models.StockOpname, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	scope := ""
+	args := make([]interface{}, 0)
+	var categoryIDs interface{}
+	if len(req.CategoryIDs) > 0 {
+		scope = " WHERE category_id = ANY($1)"
+		args = append(args, pq.Int64Array(req.CategoryIDs))
+		categoryIDs = pq.Int64Array(req.CategoryIDs)
+	}
+
+	var openID int
+	err = tx.QueryRow("SELECT id FROM stock_opnames WHERE status = $1 FOR UPDATE", models.OpnameStatusOpen).Scan(&openID)
+	if err == nil {
+		return nil, ErrOpnameAlreadyOpen
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var note *string
+	if req.Note != "" {
+		note = &req.Note
+	}
+	o, err := scanOpname(tx.QueryRow("INSERT INTO stock_opnames (category_ids, note, created_by) VALUES ($1, $2, $3) RETURNING "+opnameColumns,
+		categoryIDs, note, req.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	// dua statement terpisah: statement kedua membaca data setelah seluruh lock didapat
+	if _, err := tx.Exec("SELECT id FROM products"+scope+" ORDER BY id FOR SHARE", args...); err != nil {
+		return nil, err
+	}
+	result, err := tx.Exec(`INSERT INTO stock_opname_items (opname_id, product_id, product_name, category_id, snapshot_stock)
+		SELECT `+fmt.Sprintf("$%d", len(args)+1)+`, id, name, category_id, stock FROM products`+scope, append(args, o.ID)...)
+	if err != nil {
+		return nil, err
+	}
+	if count, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if count == 0 {
+		return nil, &models.ValidationError{Message: "tidak ada produk pada kategori yang dipilih"}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(o.ID)
+}
+
+// GetAll - daftar sesi terbaru lebih dulu beserta ringkasannya, tanpa baris produk
+func (repo *OpnameRepository) GetAll(status string) ([]models.StockOpname, error) {
+	query := "SELECT " + opnameColumns + " FROM stock_opnames"
+	args := make([]interface{}, 0)
+	if status != "" {
+		query += " WHERE status = $1"
+		args = append(args, status)
+	}
+	rows, err := repo.db.Query(query+" ORDER BY id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	opnames := make([]models.StockOpname, 0)
+	for rows.Next() {
+		o, err := scanOpname(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		opnames = append(opnames, *o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range opnames {
+		items, err := loadOpnameItems(repo.db, &opnames[i])
+		if err != nil {
+			return nil, err
+		}
+		summarizeOpname(&opnames[i], items)
+	}
+	return opnames, nil
+}
+
+// GetByID - sesi beserta variance per produk
+func (repo *OpnameRepository) GetByID(id int) (*models.StockOpname, error) {
+	o, err := scanOpname(repo.db.QueryRow("SELECT "+opnameColumns+" FROM stock_opnames WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrOpnameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := loadOpnameItems(repo.db, o)
+	if err != nil {
+		return nil, err
+	}
+	summarizeOpname(o, items)
+	o.Items = items
+	return o, nil
+}
+
+// AddCounts - simpan satu putaran hitungan beserta stok sistem saat itu. Sesi dikunci FOR SHARE agar
+// tidak di-approve di tengah jalan, produk dikunci FOR SHARE agar stok sistem yang dicatat konsisten.
+func (repo *OpnameRepository) AddCounts(opnameID int, req models.StockOpnameCountRequest) (*models.StockOpname, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenOpname(tx, opnameID, "FOR SHARE"); err != nil {
+		return nil, err
+	}
+
+	var inSession []int64
+	productIDs := make([]int64, 0, len(req.Items))
+	for _, item := range req.Items {
+		productIDs = append(productIDs, int64(item.ProductID))
+	}
+	err = tx.QueryRow("SELECT ARRAY(SELECT product_id FROM stock_opname_items WHERE opname_id = $1 AND product_id = ANY($2))",
+		opnameID, pq.Int64Array(productIDs)).Scan((*pq.Int64Array)(&inSession))
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int]bool)
+	for _, id := range inSession {
+		known[int(id)] = true
+	}
+	itemErrors := make([]models.ItemError, 0)
+	for i, item := range req.Items {
+		if !known[item.ProductID] {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "product_id", Reason: fmt.Sprintf("produk %d tidak termasuk sesi opname ini", item.ProductID)})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "hitungan stock opname tidak valid", Items: itemErrors}
+	}
+
+	if _, err := tx.Exec("SELECT id FROM products WHERE id = ANY($1) ORDER BY id FOR SHARE", pq.Int64Array(productIDs)); err != nil {
+		return nil, err
+	}
+	stocks := make(map[int]int)
+	rows, err := tx.Query("SELECT id, stock FROM products WHERE id = ANY($1)", pq.Int64Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id, stock int
+		if err := rows.Scan(&id, &stock); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stocks[id] = stock
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		// produk yang sudah dihapus dianggap stok sistemnya 0
+		_, err := tx.Exec(`INSERT INTO stock_opname_counts (opname_id, product_id, counter, quantity, system_stock, user_id)
+			VALUES ($1, $2, $3, $4, $5, $6)`, opnameID, item.ProductID, req.Counter, item.Quantity, stocks[item.ProductID], req.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(opnameID)
+}
+
+// Approve - posting variance setiap produk sebagai movement opname dalam satu transaksi database.
+// Variance dihitung terhadap stok sistem saat dihitung, sehingga penjualan setelah hitungan tetap
+// mengurangi stok: stok baru = stok saat ini + variance.
+func (repo *OpnameRepository) Approve(opnameID int, req models.ApproveOpnameRequest) (*models.StockOpname, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenOpname(tx, opnameID, "FOR UPDATE"); err != nil {
+		return nil, err
+	}
+
+	o := &models.StockOpname{ID: opnameID, Status: models.OpnameStatusOpen}
+	items, err := loadOpnameItems(tx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]int, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := lockStockProducts(tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ProductID < items[j].ProductID })
+	note := fmt.Sprintf("stock opname #%d", opnameID)
+	for i := range items {
+		item := &items[i]
+		p, exists := products[item.ProductID]
+		if item.CountedQty == nil {
+			if !req.UncountedAsZero || !exists {
+				continue
+			}
+			zero, expected := 0, p.Stock
+			item.CountedQty, item.ExpectedQty = &zero, &expected
+			variance := -expected
+			item.Variance = &variance
+		}
+
+		// produk yang sudah dihapus tetap dicatat hasilnya tanpa penyesuaian stok
+		if exists && *item.Variance != 0 {
+			movement := models.StockMovement{
+				ProductID:     item.ProductID,
+				Type:          models.StockMovementOpname,
+				Quantity:      *item.Variance,
+				ReferenceType: models.StockReferenceOpname,
+				ReferenceID:   &opnameID,
+				Note:          &note,
+				UserID:        req.UserID,
+			}
+			if err := recordStockMovement(tx, &movement); err != nil {
+				return nil, err
+			}
+			item.StockMovementID = &movement.ID
+		}
+
+		_, err := tx.Exec(`UPDATE stock_opname_items SET counted_qty = $1, expected_qty = $2, variance = $3, unit_price = $4, stock_movement_id = $5
+			WHERE opname_id = $6 AND product_id = $7`,
+			item.CountedQty, item.ExpectedQty, item.Variance, item.UnitPrice, item.StockMovementID, opnameID, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stock_opnames SET status = $1, approved_by = $2, approved_at = $3 WHERE id = $4",
+		models.OpnameStatusApproved, req.UserID, time.Now(), opnameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(opnameID)
+}
+
+// Cancel - batalkan sesi tanpa mengubah stok
+func (repo *OpnameRepository) Cancel(opnameID int) (*models.StockOpname, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenOpname(tx, opnameID, "FOR UPDATE"); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("UPDATE stock_opnames SET status = $1, cancelled_at = $2 WHERE id = $3", models.OpnameStatusCancelled, time.Now(), opnameID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(opnameID)
+}
+
+// lockOpenOpname - kunci sesi dan pastikan masih open, lock = "FOR SHARE" atau "FOR UPDATE"
+func lockOpenOpname(tx *sql.Tx, opnameID int, lock string) error {
+	var status string
+	err := tx.QueryRow("SELECT status FROM stock_opnames WHERE id = $1 "+lock, opnameID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrOpnameNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.OpnameStatusOpen {
+		return ErrOpnameClosed
+	}
+	return nil
+}
+
+// loadOpnameItems - sesi yang sudah di-approve memakai hasil yang dibekukan, sesi open dihitung
+// dari hitungan terakhir tiap penghitung dan harga jual saat ini. Penghitung adalah putaran verifikasi,
+// bukan zona: setiap hitungan mencakup seluruh stok produk sehingga tidak dijumlahkan antar penghitung.
+func loadOpnameItems(q queryer, o *models.StockOpname) ([]models.StockOpnameItem, error) {
+	rows, err := q.Query(`SELECT i.product_id, i.product_name, i.category_id, i.snapshot_stock, i.counted_qty, i.expected_qty, i.variance,
+			COALESCE(i.unit_price, p.price, 0), i.stock_movement_id
+		FROM stock_opname_items i LEFT JOIN products p ON i.product_id = p.id
+		WHERE i.opname_id = $1 ORDER BY i.product_name, i.product_id`, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]models.StockOpnameItem, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var item models.StockOpnameItem
+		err := rows.Scan(&item.ProductID, &item.ProductName, &item.CategoryID, &item.SnapshotStock, &item.CountedQty, &item.ExpectedQty, &item.Variance,
+			&item.UnitPrice, &item.StockMovementID)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[item.ProductID] = len(items)
+		items = append(items, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// hitungan terakhir per penghitung, urut id agar hitungan paling akhir yang menjadi hasil produk
+	rows, err = q.Query(`SELECT product_id, counter, quantity, system_stock, user_id, created_at FROM (
+			SELECT DISTINCT ON (product_id, counter) id, product_id, counter, quantity, system_stock, user_id, created_at
+			FROM stock_opname_counts WHERE opname_id = $1 ORDER BY product_id, counter, id DESC
+		) latest ORDER BY id`, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	open := o.Status == models.OpnameStatusOpen
+	for rows.Next() {
+		var c models.StockOpnameCount
+		var productID int
+		if err := rows.Scan(&productID, &c.Counter, &c.Quantity, &c.SystemStock, &c.UserID, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		i, ok := index[productID]
+		if !ok {
+			continue
+		}
+		item := &items[i]
+		// variance tiap hitungan terhadap stok sistem saat hitungan itu dicatat, sehingga penjualan
+		// di antara dua hitungan tidak menjadi selisih
+		c.Variance = c.Quantity - c.SystemStock
+		item.Counts = append(item.Counts, c)
+		if open {
+			counted, expected, variance := c.Quantity, c.SystemStock, c.Variance
+			item.CountedQty, item.ExpectedQty, item.Variance = &counted, &expected, &variance
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		item := &items[i]
+		if item.ExpectedQty != nil {
+			movements := *item.ExpectedQty - item.SnapshotStock
+			item.MovementsSinceSnapshot = &movements
+		}
+		if item.Variance != nil {
+			value := *item.Variance * item.UnitPrice
+			item.VarianceValue = &value
+		}
+	}
+	return items, nil
+}
+
+// summarizeOpname - ringkasan jumlah produk yang sudah dihitung dan nilai selisihnya
+func summarizeOpname(o *models.StockOpname, items []models.StockOpnameItem) {
+	o.TotalItems = len(items)
+	o.CountedItems, o.VarianceQuantity, o.VarianceValue, o.ShortageValue, o.SurplusValue = 0, 0, 0, 0, 0
+	for _, item := range items {
+		if item.CountedQty != nil {
+			o.CountedItems++
+		}
+		if item.Variance == nil {
+			continue
+		}
+		o.VarianceQuantity += *item.Variance
+		o.VarianceValue += *item.VarianceValue
+		if *item.VarianceValue < 0 {
+			o.ShortageValue -= *item.VarianceValue
+		} else {
+			o.SurplusValue += *item.VarianceValue
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+	"time"
+)
+
+// ErrPaymentIntentNotFound - payment intent dengan external_id tersebut tidak ada
+var ErrPaymentIntentNotFound = errors.New("payment intent tidak ditemukan")
+
+// ErrPaymentIntentClosed - payment intent sudah kedaluwarsa atau gagal sehingga tidak bisa dibayar
+var ErrPaymentIntentClosed = errors.New("payment intent sudah kedaluwarsa atau gagal")
+
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+const paymentIntentColumns = "id, transaction_id, payment_id, gateway, external_id, qr_string, amount, status, expires_at, paid_at, created_at"
+
+func scanPaymentIntent(row interface{ Scan(...interface{}) error }) (*models.PaymentIntent, error) {
+	var i models.PaymentIntent
+	err := row.Scan(&i.ID, &i.TransactionID, &i.PaymentID, &i.Gateway, &i.ExternalID, &i.QRString, &i.Amount, &i.Status, &i.ExpiresAt, &i.PaidAt, &i.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// AttachGatewayReference - simpan external_id dan QR dari gateway setelah intent dibuat di sisi gateway
+func (repo *PaymentRepository) AttachGatewayReference(intent *models.PaymentIntent) error {
+	_, err := repo.db.Exec("UPDATE payment_intents SET external_id = $1, qr_string = $2 WHERE id = $3", intent.ExternalID, intent.QRString, intent.ID)
+	return err
+}
+
+// CompleteIntent - tandai intent lunas dan selesaikan transaksinya bila tidak ada tender lain yang masih pending.
+// Callback yang sama boleh datang lebih dari sekali.
+func (repo *PaymentRepository) CompleteIntent(externalID string, paidAt time.Time) (*models.PaymentIntent, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	intent, err := scanPaymentIntent(tx.QueryRow("SELECT "+paymentIntentColumns+" FROM payment_intents WHERE external_id = $1 FOR UPDATE", externalID))
+	if err == sql.ErrNoRows {
+		return nil, ErrPaymentIntentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status == models.PaymentStatusPaid {
+		return intent, nil
+	}
+	if intent.Status != models.PaymentStatusPending {
+		return nil, ErrPaymentIntentClosed
+	}
+
+	_, err = tx.Exec("UPDATE payment_intents SET status = $1, paid_at = $2 WHERE id = $3", models.PaymentStatusPaid, paidAt, intent.ID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("UPDATE payments SET status = $1 WHERE id = $2", models.PaymentStatusPaid, intent.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`UPDATE transactions SET status = $1
+		WHERE id = $2 AND status = $3
+			AND NOT EXISTS (SELECT 1 FROM payments WHERE transaction_id = $2 AND status = $4)`,
+		models.TransactionStatusCompleted, intent.TransactionID, models.TransactionStatusPending, models.PaymentStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	// poin member dicatat begitu transaksi lunas
+	if err := awardTransactionPoints(tx, intent.TransactionID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	intent.Status = models.PaymentStatusPaid
+	intent.PaidAt = &paidAt
+	return intent, nil
+}
+
+// ReleaseIntent - tutup intent yang masih pending dengan status expired / failed,
+// kembalikan stok yang direservasi dan tandai transaksinya expired
+func (repo *PaymentRepository) ReleaseIntent(intentID int, status string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	released, err := releaseIntent(tx, intentID, status)
+	if err != nil {
+		return err
+	}
+	if !released {
+		return nil
+	}
+
+	return tx.Commit()
+}
+
+// ExpireDue - kedaluwarsakan semua intent pending yang melewati expires_at, mengembalikan jumlah intent
+func (repo *PaymentRepository) ExpireDue(now time.Time) (int, error) {
+	rows, err := repo.db.Query("SELECT id FROM payment_intents WHERE status = $1 AND expires_at <= $2 ORDER BY id", models.PaymentStatusPending, now)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		tx, err := repo.db.Begin()
+		if err != nil {
+			return expired, err
+		}
+		released, err := releaseIntent(tx, id, models.PaymentStatusExpired)
+		if err != nil {
+			tx.Rollback()
+			return expired, err
+		}
+		if err := tx.Commit(); err != nil {
+			return expired, err
+		}
+		if released {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// releaseIntent - false bila intent sudah tidak pending (misalnya baru saja dibayar)
+func releaseIntent(tx *sql.Tx, intentID int, status string) (bool, error) {
+	var transactionID int
+	var currentStatus string
+	err := tx.QueryRow("SELECT transaction_id, status FROM payment_intents WHERE id = $1 FOR UPDATE", intentID).Scan(&transactionID, &currentStatus)
+	if err == sql.ErrNoRows {
+		return false, ErrPaymentIntentNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	if currentStatus != models.PaymentStatusPending {
+		return false, nil
+	}
+
+	_, err = tx.Exec("UPDATE payment_intents SET status = $1 WHERE id = $2", status, intentID)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec("UPDATE payments SET status = $1 WHERE transaction_id = $2", models.PaymentStatusExpired, transactionID)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec("UPDATE transactions SET status = $1 WHERE id = $2", models.TransactionStatusExpired, transactionID)
+	if err != nil {
+		return false, err
+	}
+
+	// kembalikan stok yang direservasi, urut product_id seperti checkout
+	if err := restockTransaction(tx, transactionID); err != nil {
+		return false, err
+	}
+
+	// kembalikan poin yang dipakai sebagai pembayaran
+	if err := reverseTransactionPoints(tx, transactionID, nil, true); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// restockTransaction - kembalikan seluruh quantity transaksi ke stok produk dalam urutan product_id,
+// dicatat sebagai movement sale positif karena penjualannya batal tanpa refund
+func restockTransaction(tx *sql.Tx, transactionID int) error {
+	rows, err := tx.Query("SELECT product_id, SUM(quantity) FROM transaction_details WHERE transaction_id = $1 GROUP BY product_id ORDER BY product_id", transactionID)
+	if err != nil {
+		return err
+	}
+	restock := make([][2]int, 0)
+	for rows.Next() {
+		var productID, qty int
+		if err := rows.Scan(&productID, &qty); err != nil {
+			rows.Close()
+			return err
+		}
+		restock = append(restock, [2]int{productID, qty})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	note := "pembayaran gagal / kedaluwarsa, stok reservasi dikembalikan"
+	for _, r := range restock {
+		err := recordStockMovement(tx, &models.StockMovement{
+			ProductID:     r[0],
+			Type:          models.StockMovementSale,
+			Quantity:      r[1],
+			ReferenceType: models.StockReferenceTransaction,
+			ReferenceID:   &transactionID,
+			Note:          &note,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByTransactionID - payment intent terakhir milik transaksi, nil bila tidak ada
+func (repo *PaymentRepository) GetByTransactionID(transactionID int) (*models.PaymentIntent, error) {
+	intent, err := scanPaymentIntent(repo.db.QueryRow("SELECT "+paymentIntentColumns+" FROM payment_intents WHERE transaction_id = $1 ORDER BY id DESC LIMIT 1", transactionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
+
+func (repo *PaymentRepository) GetByExternalID(externalID string) (*models.PaymentIntent, error) {
+	intent, err := scanPaymentIntent(repo.db.QueryRow("SELECT "+paymentIntentColumns+" FROM payment_intents WHERE external_id = $1", externalID))
+	if err == sql.ErrNoRows {
+		return nil, ErrPaymentIntentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return intent, nil
+}
@@ -2,7 +2,6 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
 	"kasir-api/models"
 )
 
@@ -16,7 +15,7 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 
 func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
 	query := `
-		SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name as category_name
+		SELECT p.id, p.name, p.price, p.stock, p.cost_price, p.category_id, c.name as category_name, p.tax_class_id
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
 	`
@@ -35,7 +34,7 @@ func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
 	products := make([]models.Product, 0)
 	for rows.Next() {
 		var p models.Product
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName)
+		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CostPrice, &p.CategoryID, &p.CategoryName, &p.TaxClassID)
 		if err != nil {
 			return nil, err
 		}
@@ -45,25 +44,50 @@ func (repo *ProductRepository) GetAll(name string) ([]models.Product, error) {
 	return products, nil
 }
 
-func (repo *ProductRepository) Create(product *models.Product) error {
-	query := "INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id"
-	err := repo.db.QueryRow(query, product.Name, product.Price, product.Stock, product.CategoryID).Scan(&product.ID)
-	return err
+// Create - stok awal dicatat sebagai movement adjustment di kartu stok, cost_price menjadi harga pokok awal
+func (repo *ProductRepository) Create(product *models.Product, userID *int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO products (name, price, stock, cost_price, category_id, tax_class_id) VALUES ($1, $2, 0, $3, $4, $5) RETURNING id"
+	err = tx.QueryRow(query, product.Name, product.Price, product.CostPrice, product.CategoryID, product.TaxClassID).Scan(&product.ID)
+	if err != nil {
+		return err
+	}
+
+	note := "stok awal produk"
+	err = recordStockMovement(tx, &models.StockMovement{
+		ProductID:     product.ID,
+		Type:          models.StockMovementAdjustment,
+		Quantity:      product.Stock,
+		ReferenceType: models.StockReferenceProduct,
+		ReferenceID:   &product.ID,
+		Note:          &note,
+		UserID:        userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetByID - ambil produk by ID
 func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 	query := `
-		SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name as category_name
+		SELECT p.id, p.name, p.price, p.stock, p.cost_price, p.category_id, c.name as category_name, p.tax_class_id
 		FROM products p
 		LEFT JOIN categories c ON p.category_id = c.id
 		WHERE p.id = $1
 	`
 
 	var p models.Product
-	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName)
+	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CostPrice, &p.CategoryID, &p.CategoryName, &p.TaxClassID)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("produk tidak ditemukan")
+		return nil, ErrProductNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -72,23 +96,15 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 	return &p, nil
 }
 
+// Update - ubah data produk, field stock dan cost_price di request diabaikan dan diisi nilai sebenarnya.
+// Perubahan stok hanya lewat penyesuaian stok agar tercatat di kartu stok, harga pokok lewat penerimaan barang.
 func (repo *ProductRepository) Update(product *models.Product) error {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5"
-	result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.CategoryID, product.ID)
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+	query := "UPDATE products SET name = $1, price = $2, category_id = $3, tax_class_id = $4 WHERE id = $5 RETURNING stock, cost_price"
+	err := repo.db.QueryRow(query, product.Name, product.Price, product.CategoryID, product.TaxClassID, product.ID).Scan(&product.Stock, &product.CostPrice)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
 	}
-
-	return nil
+	return err
 }
 
 func (repo *ProductRepository) Delete(id int) error {
@@ -103,7 +119,7 @@ func (repo *ProductRepository) Delete(id int) error {
 	}
 
 	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+		return ErrProductNotFound
 	}
 
 	return err
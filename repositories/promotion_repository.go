@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+	"time"
+)
+
+type PromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+const promotionColumns = "id, name, type, value, product_id, category_id, buy_qty, get_qty, start_at, end_at, active"
+
+func scanPromotion(row interface{ Scan(...interface{}) error }) (*models.Promotion, error) {
+	var p models.Promotion
+	err := row.Scan(&p.ID, &p.Name, &p.Type, &p.Value, &p.ProductID, &p.CategoryID, &p.BuyQty, &p.GetQty, &p.StartAt, &p.EndAt, &p.Active)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (repo *PromotionRepository) query(query string, args ...interface{}) ([]models.Promotion, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := make([]models.Promotion, 0)
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, *p)
+	}
+
+	return promotions, rows.Err()
+}
+
+func (repo *PromotionRepository) GetAll() ([]models.Promotion, error) {
+	return repo.query("SELECT " + promotionColumns + " FROM promotions ORDER BY id")
+}
+
+// GetActive - promo aktif yang periode berlakunya mencakup waktu at
+func (repo *PromotionRepository) GetActive(at time.Time) ([]models.Promotion, error) {
+	query := "SELECT " + promotionColumns + ` FROM promotions
+		WHERE active = TRUE
+			AND (start_at IS NULL OR start_at <= $1)
+			AND (end_at IS NULL OR end_at > $1)
+		ORDER BY id`
+	return repo.query(query, at)
+}
+
+func (repo *PromotionRepository) Create(p *models.Promotion) error {
+	query := `INSERT INTO promotions (name, type, value, product_id, category_id, buy_qty, get_qty, start_at, end_at, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+	return repo.db.QueryRow(query, p.Name, p.Type, p.Value, p.ProductID, p.CategoryID, p.BuyQty, p.GetQty, p.StartAt, p.EndAt, p.Active).Scan(&p.ID)
+}
+
+func (repo *PromotionRepository) GetByID(id int) (*models.Promotion, error) {
+	p, err := scanPromotion(repo.db.QueryRow("SELECT "+promotionColumns+" FROM promotions WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("promo tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (repo *PromotionRepository) Update(p *models.Promotion) error {
+	query := `UPDATE promotions SET name = $1, type = $2, value = $3, product_id = $4, category_id = $5,
+		buy_qty = $6, get_qty = $7, start_at = $8, end_at = $9, active = $10 WHERE id = $11`
+	result, err := repo.db.Exec(query, p.Name, p.Type, p.Value, p.ProductID, p.CategoryID, p.BuyQty, p.GetQty, p.StartAt, p.EndAt, p.Active, p.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("promo tidak ditemukan")
+	}
+
+	return nil
+}
+
+func (repo *PromotionRepository) Delete(id int) error {
+	result, err := repo.db.Exec("DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("promo tidak ditemukan")
+	}
+
+	return nil
+}
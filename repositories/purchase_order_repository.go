@@ -0,0 +1,322 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrPurchaseOrderNotFound - purchase order dengan id tersebut tidak ada
+var ErrPurchaseOrderNotFound = errors.New("purchase order tidak ditemukan")
+
+// ErrPurchaseOrderStatus - langkah tidak diizinkan untuk status purchase order saat ini
+var ErrPurchaseOrderStatus = errors.New("status purchase order tidak mengizinkan aksi ini")
+
+// openPurchaseOrderStatuses - status purchase order yang sudah dikirim ke supplier dan masih menunggu barang
+var openPurchaseOrderStatuses = []string{
+	models.PurchaseOrderStatusSent,
+	models.PurchaseOrderStatusPartiallyReceived,
+}
+
+type PurchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db: db}
+}
+
+const purchaseOrderColumns = `po.id, po.supplier_id, s.name, po.status, po.expected_date, po.note, po.total_amount,
+	po.created_by, po.created_at, po.sent_at, po.received_at, po.cancelled_at, po.cancel_reason`
+
+func scanPurchaseOrder(row interface{ Scan(...interface{}) error }) (*models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := row.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.Status, &po.ExpectedDate, &po.Note, &po.TotalAmount,
+		&po.CreatedBy, &po.CreatedAt, &po.SentAt, &po.ReceivedAt, &po.CancelledAt, &po.CancelReason)
+	if err != nil {
+		return nil, err
+	}
+	return &po, nil
+}
+
+// GetAll - daftar purchase order terbaru lebih dulu tanpa baris, status "open" berarti belum selesai diterima
+func (repo *PurchaseOrderRepository) GetAll(filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	switch filter.Status {
+	case "":
+	case "open":
+		args = append(args, pq.Array(openPurchaseOrderStatuses))
+		conditions = append(conditions, fmt.Sprintf("po.status = ANY($%d)", len(args)))
+	default:
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("po.status = $%d", len(args)))
+	}
+	if filter.SupplierID != nil {
+		args = append(args, *filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("po.supplier_id = $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := repo.db.Query(`SELECT `+purchaseOrderColumns+`
+		FROM purchase_orders po INNER JOIN suppliers s ON po.supplier_id = s.id`+where+` ORDER BY po.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]models.PurchaseOrder, 0)
+	for rows.Next() {
+		po, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *po)
+	}
+	return orders, rows.Err()
+}
+
+func (repo *PurchaseOrderRepository) GetByID(id int) (*models.PurchaseOrder, error) {
+	po, err := scanPurchaseOrder(repo.db.QueryRow(`SELECT `+purchaseOrderColumns+`
+		FROM purchase_orders po INNER JOIN suppliers s ON po.supplier_id = s.id WHERE po.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if po.Items, err = getPurchaseOrderItems(repo.db, id); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// Create - purchase order baru berstatus draft, nama produk disalin saat baris disimpan
+func (repo *PurchaseOrderRepository) Create(req models.PurchaseOrderRequest, expectedDate *time.Time) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`INSERT INTO purchase_orders (supplier_id, status, expected_date, note, created_by)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		req.SupplierID, models.PurchaseOrderStatusDraft, expectedDate, req.Note, req.UserID).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	if err := savePurchaseOrderItems(tx, id, req.Items); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Update - ganti supplier, tanggal, catatan dan seluruh baris, hanya selama draft
+func (repo *PurchaseOrderRepository) Update(id int, req models.PurchaseOrderRequest, expectedDate *time.Time) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status != models.PurchaseOrderStatusDraft {
+		return nil, ErrPurchaseOrderStatus
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET supplier_id = $1, expected_date = $2, note = $3 WHERE id = $4",
+		req.SupplierID, expectedDate, req.Note, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM purchase_order_items WHERE purchase_order_id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := savePurchaseOrderItems(tx, id, req.Items); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Send - draft dikirim ke supplier, baris tidak bisa diubah lagi
+func (repo *PurchaseOrderRepository) Send(id int) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status != models.PurchaseOrderStatusDraft {
+		return nil, ErrPurchaseOrderStatus
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET status = $1, sent_at = CURRENT_TIMESTAMP WHERE id = $2",
+		models.PurchaseOrderStatusSent, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Cancel - batalkan purchase order yang belum selesai diterima, barang yang sudah diterima tetap di stok
+func (repo *PurchaseOrderRepository) Cancel(id int, reason string) (*models.PurchaseOrder, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status == models.PurchaseOrderStatusReceived || status == models.PurchaseOrderStatusCancelled {
+		return nil, ErrPurchaseOrderStatus
+	}
+
+	var cancelReason *string
+	if reason != "" {
+		cancelReason = &reason
+	}
+	_, err = tx.Exec("UPDATE purchase_orders SET status = $1, cancelled_at = CURRENT_TIMESTAMP, cancel_reason = $2 WHERE id = $3",
+		models.PurchaseOrderStatusCancelled, cancelReason, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// lockPurchaseOrder - kunci purchase order selama perubahan status dan kembalikan status saat ini
+func lockPurchaseOrder(tx *sql.Tx, id int) (string, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", ErrPurchaseOrderNotFound
+	}
+	return status, err
+}
+
+// refreshPurchaseOrderStatus - status setelah received_qty berubah lewat penerimaan barang atau pembatalannya:
+// received bila seluruh baris lengkap, partially_received bila sebagian, sent bila belum ada yang diterima
+func refreshPurchaseOrderStatus(tx *sql.Tx, id int) error {
+	var complete, started bool
+	err := tx.QueryRow(`SELECT COALESCE(BOOL_AND(received_qty = quantity), FALSE), COALESCE(BOOL_OR(received_qty > 0), FALSE)
+		FROM purchase_order_items WHERE purchase_order_id = $1`, id).Scan(&complete, &started)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case complete:
+		_, err = tx.Exec("UPDATE purchase_orders SET status = $1, received_at = COALESCE(received_at, CURRENT_TIMESTAMP) WHERE id = $2",
+			models.PurchaseOrderStatusReceived, id)
+	case started:
+		_, err = tx.Exec("UPDATE purchase_orders SET status = $1, received_at = NULL WHERE id = $2", models.PurchaseOrderStatusPartiallyReceived, id)
+	default:
+		_, err = tx.Exec("UPDATE purchase_orders SET status = $1, received_at = NULL WHERE id = $2", models.PurchaseOrderStatusSent, id)
+	}
+	return err
+}
+
+// savePurchaseOrderItems - simpan baris dan hitung ulang total, produk yang tidak ada menjadi ValidationError
+func savePurchaseOrderItems(tx *sql.Tx, purchaseOrderID int, items []models.PurchaseOrderItemRequest) error {
+	productIDs := make([]int, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	rows, err := tx.Query("SELECT id, name FROM products WHERE id = ANY($1)", pq.Array(productIDs))
+	if err != nil {
+		return err
+	}
+	names := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		names[id] = name
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	itemErrors := make([]models.ItemError, 0)
+	for i, item := range items {
+		if _, ok := names[item.ProductID]; !ok {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "product_id", Reason: fmt.Sprintf("produk %d tidak ditemukan", item.ProductID)})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return &models.ValidationError{Message: "baris purchase order tidak valid", Items: itemErrors}
+	}
+
+	total := 0
+	for _, item := range items {
+		_, err := tx.Exec(`INSERT INTO purchase_order_items (purchase_order_id, product_id, product_name, quantity, unit_cost)
+			VALUES ($1, $2, $3, $4, $5)`,
+			purchaseOrderID, item.ProductID, names[item.ProductID], item.Quantity, item.UnitCost)
+		if err != nil {
+			return err
+		}
+		total += item.Quantity * item.UnitCost
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET total_amount = $1 WHERE id = $2", total, purchaseOrderID)
+	return err
+}
+
+func getPurchaseOrderItems(q queryer, purchaseOrderID int) ([]models.PurchaseOrderItem, error) {
+	rows, err := q.Query(`SELECT id, product_id, product_name, quantity, unit_cost, received_qty
+		FROM purchase_order_items WHERE purchase_order_id = $1 ORDER BY id`, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.PurchaseOrderItem, 0)
+	for rows.Next() {
+		var item models.PurchaseOrderItem
+		if err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.UnitCost, &item.ReceivedQty); err != nil {
+			return nil, err
+		}
+		item.Subtotal = item.Quantity * item.UnitCost
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
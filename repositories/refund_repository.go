@@ -0,0 +1,446 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"sort"
+	"time"
+)
+
+// ErrTransactionVoided - transaksi sudah di-void sehingga tidak bisa di-refund lagi
+var ErrTransactionVoided = errors.New("transaksi sudah di-void")
+
+// ErrTransactionNotPaid - transaksi masih menunggu pembayaran atau sudah kedaluwarsa
+var ErrTransactionNotPaid = errors.New("transaksi belum dibayar")
+
+// ErrNothingToRefund - seluruh item transaksi sudah dikembalikan
+var ErrNothingToRefund = errors.New("seluruh item transaksi sudah dikembalikan")
+
+type RefundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+// refundableLine - baris transaksi beserta jumlah yang sudah pernah dikembalikan,
+// netAmount adalah nilai akhir baris (setelah diskon dan pajak) yang menjadi dasar nominal refund
+type refundableLine struct {
+	detail         models.TransactionDetail
+	netAmount      int
+	refundedQty    int
+	refundedAmount int
+}
+
+// CreateRefund - membuat dokumen void / refund, mengembalikan stok dan memperbarui status transaksi
+// dalam satu transaksi database. Void selalu membalik seluruh sisa item transaksi.
+func (repo *RefundRepository) CreateRefund(transactionID int, refundType string, req models.RefundRequest) (*models.Refund, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// uang refund keluar dari laci shift yang sedang open, shift dikunci lebih dulu seperti checkout
+	shiftID, err := currentShiftID(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// kunci transaksi agar dua refund bersamaan tidak melebihi quantity asal
+	var status string
+	err = tx.QueryRow("SELECT status FROM transactions WHERE id = $1 FOR UPDATE", transactionID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status == models.TransactionStatusVoided {
+		return nil, ErrTransactionVoided
+	}
+	if status == models.TransactionStatusPending || status == models.TransactionStatusExpired {
+		return nil, ErrTransactionNotPaid
+	}
+
+	// refund dicatat pada tanggal refund dibuat, bukan tanggal transaksi asal, sehingga transaksi dari
+	// hari yang sudah disegel laporan Z tetap bisa di-refund selama hari ini belum ditutup
+	if err := ensureDayOpen(tx, time.Now()); err != nil {
+		return nil, err
+	}
+
+	lines, order, err := loadRefundableLines(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	quantities := make(map[int]int)
+	if refundType == models.RefundTypeVoid {
+		for _, id := range order {
+			if remaining := lines[id].detail.Quantity - lines[id].refundedQty; remaining > 0 {
+				quantities[id] = remaining
+			}
+		}
+		if len(quantities) == 0 {
+			return nil, ErrNothingToRefund
+		}
+	} else {
+		itemErrors := make([]models.ItemError, 0)
+		for i, item := range req.Items {
+			line, ok := lines[item.TransactionDetailID]
+			if !ok {
+				itemErrors = append(itemErrors, models.ItemError{Index: i, Field: "transaction_detail_id", Reason: fmt.Sprintf("detail %d bukan bagian dari transaksi ini", item.TransactionDetailID)})
+				continue
+			}
+			remaining := line.detail.Quantity - line.refundedQty - quantities[item.TransactionDetailID]
+			if item.Quantity > remaining {
+				itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: line.detail.ProductID, Field: "quantity", Reason: fmt.Sprintf("quantity melebihi sisa yang bisa dikembalikan (%d)", remaining)})
+				continue
+			}
+			quantities[item.TransactionDetailID] += item.Quantity
+		}
+		if len(itemErrors) > 0 {
+			return nil, &models.ValidationError{Message: "item refund tidak valid", Items: itemErrors}
+		}
+	}
+
+	refund := models.Refund{
+		TransactionID: transactionID,
+		Type:          refundType,
+		Reason:        req.Reason,
+		ShiftID:       shiftID,
+		UserID:        req.UserID,
+		Details:       make([]models.RefundDetail, 0, len(quantities)),
+	}
+	restock := make(map[int]int)
+	for _, id := range order {
+		qty, ok := quantities[id]
+		if !ok {
+			continue
+		}
+		line := lines[id]
+
+		// baris yang dikembalikan seluruhnya memakai sisa nilai bersih agar tidak ada selisih pembulatan
+		amount := line.netAmount * qty / line.detail.Quantity
+		if line.refundedQty+qty == line.detail.Quantity {
+			amount = line.netAmount - line.refundedAmount
+		}
+
+		refund.TotalAmount += amount
+		restock[line.detail.ProductID] += qty
+		refund.Details = append(refund.Details, models.RefundDetail{
+			TransactionDetailID: id,
+			ProductID:           line.detail.ProductID,
+			Quantity:            qty,
+			Amount:              amount,
+		})
+	}
+
+	err = tx.QueryRow("INSERT INTO refunds (transaction_id, type, reason, total_amount, shift_id, user_id) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at",
+		transactionID, refundType, req.Reason, refund.TotalAmount, refund.ShiftID, refund.UserID).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range refund.Details {
+		d := &refund.Details[i]
+		d.RefundID = refund.ID
+		err = tx.QueryRow("INSERT INTO refund_details (refund_id, transaction_detail_id, product_id, quantity, amount) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			refund.ID, d.TransactionDetailID, d.ProductID, d.Quantity, d.Amount).Scan(&d.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// kembalikan stok dalam urutan product_id, sama seperti checkout, agar tidak deadlock
+	productIDs := make([]int, 0, len(restock))
+	for id := range restock {
+		productIDs = append(productIDs, id)
+	}
+	sort.Ints(productIDs)
+	for _, id := range productIDs {
+		err = recordStockMovement(tx, &models.StockMovement{
+			ProductID:     id,
+			Type:          models.StockMovementRefund,
+			Quantity:      restock[id],
+			ReferenceType: models.StockReferenceRefund,
+			ReferenceID:   &refund.ID,
+			UserID:        refund.UserID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	newStatus := models.TransactionStatusVoided
+	if refundType == models.RefundTypeRefund {
+		newStatus = models.TransactionStatusRefunded
+		for _, id := range order {
+			if lines[id].refundedQty+quantities[id] < lines[id].detail.Quantity {
+				newStatus = models.TransactionStatusPartiallyRefunded
+				break
+			}
+		}
+	}
+	_, err = tx.Exec("UPDATE transactions SET status = $1 WHERE id = $2", newStatus, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// refund transaksi kasbon lebih dulu memotong sisa piutangnya
+	refund.CreditedAmount, err = creditReceivable(tx, transactionID, refund.TotalAmount)
+	if err != nil {
+		return nil, err
+	}
+	if refund.CreditedAmount > 0 {
+		_, err = tx.Exec("UPDATE refunds SET credited_amount = $1 WHERE id = $2", refund.CreditedAmount, refund.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// sisanya dikembalikan lewat tender asal, bagian tender poin dikembalikan sebagai poin
+	refund.Payments, err = allocateRefundPayments(tx, transactionID, refund.TotalAmount-refund.CreditedAmount)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range refund.Payments {
+		_, err := tx.Exec("INSERT INTO refund_payments (refund_id, method, amount, points) VALUES ($1, $2, $3, $4)",
+			refund.ID, p.Method, p.Amount, p.Points)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// kurangi poin yang didapat sesuai nominal refund dan kembalikan bagian tender poin ke saldo member
+	if err := reverseTransactionPoints(tx, transactionID, &refund.ID, newStatus != models.TransactionStatusPartiallyRefunded); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// refundTender - tender pembayaran transaksi beserta sisa nominal yang belum dikembalikan
+type refundTender struct {
+	method    string
+	remaining int
+	share     int
+}
+
+// allocateRefundPayments - bagi nominal refund ke tender pembayaran asal, proporsional terhadap sisa tiap tender
+// yang belum dikembalikan sehingga refund penuh selalu mengembalikan setiap tender persis. Bagian tender poin
+// dibulatkan ke bawah ke kelipatan nilai poin. Sisa pembulatan masuk ke tender uang lain, dan nominal yang
+// melebihi seluruh tender (misalnya cicilan kasbon yang sudah dibayar) dikembalikan tunai.
+func allocateRefundPayments(tx *sql.Tx, transactionID, amount int) ([]models.RefundPayment, error) {
+	payments := make([]models.RefundPayment, 0)
+	if amount <= 0 {
+		return payments, nil
+	}
+
+	rows, err := tx.Query(`
+		SELECT p.method, SUM(p.amount) - COALESCE((
+			SELECT SUM(rp.amount) FROM refund_payments rp INNER JOIN refunds r ON rp.refund_id = r.id
+			WHERE r.transaction_id = p.transaction_id AND rp.method = p.method), 0)
+		FROM payments p
+		WHERE p.transaction_id = $1 AND p.status = $2
+		GROUP BY p.transaction_id, p.method
+		ORDER BY p.method
+	`, transactionID, models.PaymentStatusPaid)
+	if err != nil {
+		return nil, err
+	}
+	tenders := make([]*refundTender, 0)
+	totalRemaining := 0
+	for rows.Next() {
+		var t refundTender
+		if err := rows.Scan(&t.method, &t.remaining); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if t.remaining <= 0 {
+			continue
+		}
+		tenders = append(tenders, &t)
+		totalRemaining += t.remaining
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// nilai rupiah satu poin pada transaksi ini
+	pointValue := 0
+	err = tx.QueryRow(`SELECT COALESCE(SUM(p.amount) / NULLIF(t.points_redeemed, 0), 0)
+		FROM transactions t LEFT JOIN payments p ON p.transaction_id = t.id AND p.method = $2
+		WHERE t.id = $1 GROUP BY t.points_redeemed`, transactionID, models.PaymentMethodPoints).Scan(&pointValue)
+	if err != nil {
+		return nil, err
+	}
+
+	allocated := amount
+	if allocated > totalRemaining {
+		allocated = totalRemaining
+	}
+	left := allocated
+	for _, t := range tenders {
+		t.share = int(int64(allocated) * int64(t.remaining) / int64(totalRemaining))
+		if t.method == models.PaymentMethodPoints {
+			if pointValue > 0 {
+				t.share -= t.share % pointValue
+			} else {
+				t.share = 0
+			}
+		}
+		left -= t.share
+	}
+	for _, t := range tenders {
+		if left == 0 {
+			break
+		}
+		if t.method == models.PaymentMethodPoints {
+			continue
+		}
+		extra := t.remaining - t.share
+		if extra > left {
+			extra = left
+		}
+		t.share += extra
+		left -= extra
+	}
+	cash := amount - allocated + left
+
+	for _, t := range tenders {
+		if t.method == models.PaymentMethodCash {
+			t.share += cash
+			cash = 0
+		}
+		if t.share <= 0 {
+			continue
+		}
+		p := models.RefundPayment{Method: t.method, Amount: t.share}
+		if t.method == models.PaymentMethodPoints {
+			p.Points = t.share / pointValue
+		}
+		payments = append(payments, p)
+	}
+	if cash > 0 {
+		payments = append(payments, models.RefundPayment{Method: models.PaymentMethodCash, Amount: cash})
+	}
+	return payments, nil
+}
+
+// loadRefundableLines - ambil baris transaksi beserta total quantity dan nominal yang sudah di-refund
+func loadRefundableLines(tx *sql.Tx, transactionID int) (map[int]*refundableLine, []int, error) {
+	query := `
+		SELECT td.id, td.product_id, td.quantity, td.subtotal,
+			td.subtotal - td.discount_amount + CASE WHEN td.tax_inclusive THEN 0 ELSE td.tax_amount END,
+			COALESCE(SUM(rd.quantity), 0), COALESCE(SUM(rd.amount), 0)
+		FROM transaction_details td
+		LEFT JOIN refund_details rd ON rd.transaction_detail_id = td.id
+		WHERE td.transaction_id = $1
+		GROUP BY td.id, td.product_id, td.quantity, td.subtotal, td.discount_amount, td.tax_inclusive, td.tax_amount
+		ORDER BY td.id
+	`
+	rows, err := tx.Query(query, transactionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	lines := make(map[int]*refundableLine)
+	order := make([]int, 0)
+	for rows.Next() {
+		var l refundableLine
+		err := rows.Scan(&l.detail.ID, &l.detail.ProductID, &l.detail.Quantity, &l.detail.Subtotal, &l.netAmount, &l.refundedQty, &l.refundedAmount)
+		if err != nil {
+			return nil, nil, err
+		}
+		l.detail.TransactionID = transactionID
+		lines[l.detail.ID] = &l
+		order = append(order, l.detail.ID)
+	}
+
+	return lines, order, rows.Err()
+}
+
+// GetByTransactionID - daftar void / refund untuk sebuah transaksi
+func (repo *RefundRepository) GetByTransactionID(transactionID int) ([]models.Refund, error) {
+	rows, err := repo.db.Query("SELECT id, transaction_id, type, reason, total_amount, credited_amount, shift_id, user_id, created_at FROM refunds WHERE transaction_id = $1 ORDER BY id", transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := make([]models.Refund, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		var r models.Refund
+		err := rows.Scan(&r.ID, &r.TransactionID, &r.Type, &r.Reason, &r.TotalAmount, &r.CreditedAmount, &r.ShiftID, &r.UserID, &r.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		r.Details = make([]models.RefundDetail, 0)
+		r.Payments = make([]models.RefundPayment, 0)
+		index[r.ID] = len(refunds)
+		refunds = append(refunds, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	detailRows, err := repo.db.Query(`
+		SELECT rd.id, rd.refund_id, rd.transaction_detail_id, rd.product_id, rd.quantity, rd.amount
+		FROM refund_details rd
+		INNER JOIN refunds r ON rd.refund_id = r.id
+		WHERE r.transaction_id = $1
+		ORDER BY rd.id
+	`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer detailRows.Close()
+
+	for detailRows.Next() {
+		var d models.RefundDetail
+		err := detailRows.Scan(&d.ID, &d.RefundID, &d.TransactionDetailID, &d.ProductID, &d.Quantity, &d.Amount)
+		if err != nil {
+			return nil, err
+		}
+		r := &refunds[index[d.RefundID]]
+		r.Details = append(r.Details, d)
+	}
+	if err := detailRows.Err(); err != nil {
+		return nil, err
+	}
+
+	paymentRows, err := repo.db.Query(`
+		SELECT rp.refund_id, rp.method, rp.amount, rp.points
+		FROM refund_payments rp
+		INNER JOIN refunds r ON rp.refund_id = r.id
+		WHERE r.transaction_id = $1
+		ORDER BY rp.id
+	`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer paymentRows.Close()
+
+	for paymentRows.Next() {
+		var refundID int
+		var p models.RefundPayment
+		if err := paymentRows.Scan(&refundID, &p.Method, &p.Amount, &p.Points); err != nil {
+			return nil, err
+		}
+		r := &refunds[index[refundID]]
+		r.Payments = append(r.Payments, p)
+	}
+
+	return refunds, paymentRows.Err()
+}
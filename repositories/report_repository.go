@@ -2,8 +2,8 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
 	"kasir-api/models"
-	"time"
 )
 
 // ReportRepository - repository untuk laporan
@@ -16,51 +16,123 @@ func NewReportRepository(db *sql.DB) *ReportRepository {
 	return &ReportRepository{db: db}
 }
 
-// GetTotalRevenue - menghitung total revenue dalam rentang tanggal
-func (repo *ReportRepository) GetTotalRevenue(startDate, endDate time.Time) (int, error) {
-	query := `
-		SELECT COALESCE(SUM(total_amount), 0) 
-		FROM transactions 
-		WHERE created_at >= $1 AND created_at < $2
-	`
+// reportConditions - kondisi transaksi yang terjual (lunas, termasuk yang kemudian di-refund / void) dalam
+// rentang tanggal filter beserta argumennya, dipakai semua query laporan dengan alias t untuk transactions.
+// Pending dan expired tidak dihitung karena belum / tidak pernah dibayar.
+func reportConditions(filter models.ReportFilter) (string, []interface{}) {
+	conditions := "t.created_at >= $1 AND t.created_at < $2 AND t.status IN ('completed', 'partially_refunded', 'refunded', 'voided')"
+	args := []interface{}{filter.StartDate, filter.EndDate}
+	if filter.CustomerID != nil {
+		args = append(args, *filter.CustomerID)
+		conditions += fmt.Sprintf(" AND t.customer_id = $%d", len(args))
+	}
+	return conditions, args
+}
+
+// refundConditions - kondisi refund / void (alias r) yang dibuat dalam rentang tanggal filter, t adalah transaksi
+// asalnya. Argumennya sama dengan reportConditions. Refund dicatat pada tanggal refund, bukan tanggal transaksi
+// asal, sehingga angka tanggal yang sudah disegel laporan Z tidak berubah.
+func refundConditions(filter models.ReportFilter) string {
+	conditions := "r.created_at >= $1 AND r.created_at < $2"
+	if filter.CustomerID != nil {
+		conditions += " AND t.customer_id = $3"
+	}
+	return conditions
+}
+
+// GetTotalRevenue - menghitung revenue bersih dalam rentang tanggal: total transaksi yang terjual
+// dikurangi nominal refund / void yang dibuat dalam rentang yang sama
+func (repo *ReportRepository) GetTotalRevenue(filter models.ReportFilter) (int, error) {
+	where, args := reportConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT COALESCE((SELECT SUM(t.total_amount) FROM transactions t WHERE %s), 0)
+			- COALESCE((
+				SELECT SUM(r.total_amount)
+				FROM refunds r
+				INNER JOIN transactions t ON r.transaction_id = t.id
+				WHERE %s
+			), 0)
+	`, where, refundConditions(filter))
 	var totalRevenue int
-	err := repo.db.QueryRow(query, startDate, endDate).Scan(&totalRevenue)
+	err := repo.db.QueryRow(query, args...).Scan(&totalRevenue)
 	if err != nil {
 		return 0, err
 	}
 	return totalRevenue, nil
 }
 
-// GetTotalTransactions - menghitung total transaksi dalam rentang tanggal
-func (repo *ReportRepository) GetTotalTransactions(startDate, endDate time.Time) (int, error) {
-	query := `
-		SELECT COUNT(*) 
-		FROM transactions 
-		WHERE created_at >= $1 AND created_at < $2
-	`
+// GetSalesSummary - total penjualan kotor dan diskon transaksi dalam rentang tanggal,
+// refund adalah nominal refund / void yang dibuat dalam rentang tersebut
+func (repo *ReportRepository) GetSalesSummary(filter models.ReportFilter) (*models.SalesSummary, error) {
+	where, args := reportConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT COALESCE(SUM(t.subtotal_amount), 0), COALESCE(SUM(t.discount_amount), 0),
+			COALESCE((
+				SELECT SUM(r.total_amount)
+				FROM refunds r
+				INNER JOIN transactions t ON r.transaction_id = t.id
+				WHERE %s
+			), 0)
+		FROM transactions t
+		WHERE %s
+	`, refundConditions(filter), where)
+	var summary models.SalesSummary
+	err := repo.db.QueryRow(query, args...).Scan(&summary.GrossSales, &summary.TotalDiscount, &summary.TotalRefund)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GetTotalTransactions - jumlah transaksi yang terjual dalam rentang tanggal dikurangi void yang dibuat
+// dalam rentang tersebut
+func (repo *ReportRepository) GetTotalTransactions(filter models.ReportFilter) (int, error) {
+	where, args := reportConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT (SELECT COUNT(*) FROM transactions t WHERE %s)
+			- (
+				SELECT COUNT(*)
+				FROM refunds r
+				INNER JOIN transactions t ON r.transaction_id = t.id
+				WHERE %s AND r.type = 'void'
+			)
+	`, where, refundConditions(filter))
 	var totalTransactions int
-	err := repo.db.QueryRow(query, startDate, endDate).Scan(&totalTransactions)
+	err := repo.db.QueryRow(query, args...).Scan(&totalTransactions)
 	if err != nil {
 		return 0, err
 	}
 	return totalTransactions, nil
 }
 
-// GetBestSellingProduct - mendapatkan produk terlaris dalam rentang tanggal
-func (repo *ReportRepository) GetBestSellingProduct(startDate, endDate time.Time) (*models.ProdukTerlaris, error) {
-	query := `
-		SELECT p.name, COALESCE(SUM(td.quantity), 0) as total_qty
-		FROM products p
-		INNER JOIN transaction_details td ON p.id = td.product_id
-		INNER JOIN transactions t ON td.transaction_id = t.id
-		WHERE t.created_at >= $1 AND t.created_at < $2
-		GROUP BY p.id, p.name
+// GetBestSellingProduct - mendapatkan produk terlaris dalam rentang tanggal berdasarkan quantity terjual
+// dikurangi quantity yang di-refund dalam rentang tersebut.
+// Nama produk diambil dari snapshot transaksi terakhir, bukan dari tabel products.
+func (repo *ReportRepository) GetBestSellingProduct(filter models.ReportFilter) (*models.ProdukTerlaris, error) {
+	where, args := reportConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT (ARRAY_AGG(s.product_name ORDER BY s.detail_id DESC))[1], COALESCE(SUM(s.quantity), 0) as total_qty
+		FROM (
+			SELECT td.product_id, td.product_name, td.id AS detail_id, td.quantity
+			FROM transaction_details td
+			INNER JOIN transactions t ON td.transaction_id = t.id
+			WHERE %s
+			UNION ALL
+			SELECT td.product_id, td.product_name, td.id, -rd.quantity
+			FROM refund_details rd
+			INNER JOIN refunds r ON rd.refund_id = r.id
+			INNER JOIN transaction_details td ON rd.transaction_detail_id = td.id
+			INNER JOIN transactions t ON r.transaction_id = t.id
+			WHERE %s
+		) s
+		GROUP BY s.product_id
+		HAVING SUM(s.quantity) > 0
 		ORDER BY total_qty DESC
 		LIMIT 1
-	`
+	`, where, refundConditions(filter))
 	
 	var product models.ProdukTerlaris
-	err := repo.db.QueryRow(query, startDate, endDate).Scan(&product.Nama, &product.QtyTerjual)
+	err := repo.db.QueryRow(query, args...).Scan(&product.Nama, &product.QtyTerjual)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -69,3 +141,177 @@ func (repo *ReportRepository) GetBestSellingProduct(startDate, endDate time.Time
 	}
 	return &product, nil
 }
+
+// GetTaxSummary - ringkasan pajak per kelas pajak dalam rentang tanggal, dikurangi porsi item yang di-refund
+// dalam rentang tersebut
+func (repo *ReportRepository) GetTaxSummary(filter models.ReportFilter) ([]models.TaxSummary, error) {
+	where, args := reportConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT s.tax_class_name, s.tax_rate, COALESCE(SUM(s.taxable), 0), COALESCE(SUM(s.tax), 0)
+		FROM (
+			SELECT td.tax_class_name, td.tax_rate,
+				td.subtotal - td.discount_amount - CASE WHEN td.tax_inclusive THEN td.tax_amount ELSE 0 END AS taxable,
+				td.tax_amount AS tax
+			FROM transaction_details td
+			INNER JOIN transactions t ON td.transaction_id = t.id
+			WHERE %s AND td.tax_class_id IS NOT NULL
+			UNION ALL
+			SELECT td.tax_class_name, td.tax_rate,
+				-((td.subtotal - td.discount_amount - CASE WHEN td.tax_inclusive THEN td.tax_amount ELSE 0 END) * rd.quantity / td.quantity),
+				-(td.tax_amount * rd.quantity / td.quantity)
+			FROM refund_details rd
+			INNER JOIN refunds r ON rd.refund_id = r.id
+			INNER JOIN transaction_details td ON rd.transaction_detail_id = td.id
+			INNER JOIN transactions t ON r.transaction_id = t.id
+			WHERE %s AND td.tax_class_id IS NOT NULL
+		) s
+		GROUP BY s.tax_class_name, s.tax_rate
+		ORDER BY s.tax_class_name
+	`, where, refundConditions(filter))
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]models.TaxSummary, 0)
+	for rows.Next() {
+		var t models.TaxSummary
+		err := rows.Scan(&t.TaxClassName, &t.Rate, &t.TaxableAmount, &t.TaxAmount)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, t)
+	}
+
+	return summaries, rows.Err()
+}
+
+// GetPaymentSummary - total pembayaran bersih per metode dalam rentang tanggal, bagian refund yang
+// dikembalikan lewat metode tersebut (refund_payments) dalam rentang yang sama mengurangi totalnya
+func (repo *ReportRepository) GetPaymentSummary(filter models.ReportFilter) ([]models.PaymentMethodSummary, error) {
+	where, args := reportConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT m.method, COALESCE(SUM(m.amount), 0), COUNT(DISTINCT m.transaction_id)
+		FROM (
+			SELECT p.method, p.amount, p.transaction_id
+			FROM payments p
+			INNER JOIN transactions t ON p.transaction_id = t.id
+			WHERE %s
+			UNION ALL
+			SELECT rp.method, -rp.amount, NULL
+			FROM refund_payments rp
+			INNER JOIN refunds r ON rp.refund_id = r.id
+			INNER JOIN transactions t ON r.transaction_id = t.id
+			WHERE %s
+		) m
+		GROUP BY m.method
+		ORDER BY m.method
+	`, where, refundConditions(filter))
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make([]models.PaymentMethodSummary, 0)
+	for rows.Next() {
+		var p models.PaymentMethodSummary
+		err := rows.Scan(&p.Method, &p.Amount, &p.Count)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, p)
+	}
+
+	return summaries, rows.Err()
+}
+
+// GetItemCount - jumlah item terjual dalam rentang tanggal dikurangi item yang di-refund dalam rentang tersebut
+func (repo *ReportRepository) GetItemCount(filter models.ReportFilter) (int, error) {
+	where, args := reportConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT COALESCE((
+				SELECT SUM(td.quantity)
+				FROM transaction_details td
+				INNER JOIN transactions t ON td.transaction_id = t.id
+				WHERE %s
+			), 0)
+			- COALESCE((
+				SELECT SUM(rd.quantity)
+				FROM refund_details rd
+				INNER JOIN refunds r ON rd.refund_id = r.id
+				INNER JOIN transactions t ON r.transaction_id = t.id
+				WHERE %s
+			), 0)
+	`, where, refundConditions(filter))
+	var itemCount int
+	err := repo.db.QueryRow(query, args...).Scan(&itemCount)
+	if err != nil {
+		return 0, err
+	}
+	return itemCount, nil
+}
+
+// GetHourlySales - jumlah transaksi dan revenue bersih per jam dalam rentang tanggal,
+// refund / void dikurangkan pada jam refund dibuat
+func (repo *ReportRepository) GetHourlySales(filter models.ReportFilter) ([]models.HourlySales, error) {
+	where, args := reportConditions(filter)
+	query := fmt.Sprintf(`
+		SELECT s.hour, COALESCE(SUM(s.counted), 0), COALESCE(SUM(s.amount), 0)
+		FROM (
+			SELECT EXTRACT(HOUR FROM t.created_at)::int AS hour, 1 AS counted, t.total_amount AS amount
+			FROM transactions t
+			WHERE %s
+			UNION ALL
+			SELECT EXTRACT(HOUR FROM r.created_at)::int, CASE WHEN r.type = 'void' THEN -1 ELSE 0 END, -r.total_amount
+			FROM refunds r
+			INNER JOIN transactions t ON r.transaction_id = t.id
+			WHERE %s
+		) s
+		GROUP BY s.hour
+		ORDER BY s.hour
+	`, where, refundConditions(filter))
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hourly := make([]models.HourlySales, 0)
+	for rows.Next() {
+		var h models.HourlySales
+		if err := rows.Scan(&h.Hour, &h.TotalTransaksi, &h.TotalRevenue); err != nil {
+			return nil, err
+		}
+		hourly = append(hourly, h)
+	}
+
+	return hourly, rows.Err()
+}
+
+// GetInvoiceRange - nomor invoice pertama dan terakhir yang terbit dalam rentang tanggal,
+// termasuk transaksi void / expired karena nomor invoice tidak boleh hilang dari audit
+func (repo *ReportRepository) GetInvoiceRange(filter models.ReportFilter) (*string, *string, error) {
+	var first, last *string
+	err := repo.db.QueryRow(`
+		SELECT
+			(SELECT invoice_number FROM transactions WHERE created_at >= $1 AND created_at < $2 ORDER BY id LIMIT 1),
+			(SELECT invoice_number FROM transactions WHERE created_at >= $1 AND created_at < $2 ORDER BY id DESC LIMIT 1)
+	`, filter.StartDate, filter.EndDate).Scan(&first, &last)
+	if err != nil {
+		return nil, nil, err
+	}
+	return first, last, nil
+}
+
+// CountPending - transaksi QRIS / e-wallet yang masih menunggu pembayaran dalam rentang tanggal
+func (repo *ReportRepository) CountPending(filter models.ReportFilter) (int, error) {
+	var count int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM transactions WHERE created_at >= $1 AND created_at < $2 AND status = $3",
+		filter.StartDate, filter.EndDate, models.TransactionStatusPending).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
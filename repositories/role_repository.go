@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrRoleNotFound - role dengan id tersebut tidak ada
+var ErrRoleNotFound = errors.New("role tidak ditemukan")
+
+// ErrOwnerRoleLocked - permission role owner tidak bisa diubah
+var ErrOwnerRoleLocked = errors.New("role owner selalu memiliki seluruh permission dan tidak bisa diubah")
+
+// ErrLastOwner - perubahan ditolak karena toko tidak akan punya owner aktif lagi
+var ErrLastOwner = errors.New("minimal harus ada satu owner aktif")
+
+type RoleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+const roleColumns = `r.id, r.name, r.description,
+	ARRAY(SELECT rp.permission_code FROM role_permissions rp WHERE rp.role_id = r.id ORDER BY rp.permission_code)`
+
+func scanRole(row interface{ Scan(...interface{}) error }) (*models.Role, error) {
+	var r models.Role
+	err := row.Scan(&r.ID, &r.Name, &r.Description, pq.Array(&r.Permissions))
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (repo *RoleRepository) GetAll() ([]models.Role, error) {
+	rows, err := repo.db.Query("SELECT " + roleColumns + " FROM roles r ORDER BY r.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make([]models.Role, 0)
+	for rows.Next() {
+		r, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *r)
+	}
+
+	return roles, rows.Err()
+}
+
+func (repo *RoleRepository) GetByID(id int) (*models.Role, error) {
+	r, err := scanRole(repo.db.QueryRow("SELECT "+roleColumns+" FROM roles r WHERE r.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrRoleNotFound
+	}
+	return r, err
+}
+
+func (repo *RoleRepository) GetPermissions() ([]models.Permission, error) {
+	rows, err := repo.db.Query("SELECT code, description FROM permissions ORDER BY code")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := make([]models.Permission, 0)
+	for rows.Next() {
+		var p models.Permission
+		if err := rows.Scan(&p.Code, &p.Description); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+
+	return permissions, rows.Err()
+}
+
+// Create - role baru beserta permission-nya dalam satu transaksi database
+func (repo *RoleRepository) Create(req models.RoleRequest) (*models.Role, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow("INSERT INTO roles (name, description) VALUES ($1, $2) RETURNING id", req.Name, req.Description).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	if err := setRolePermissions(tx, id, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// Update - ganti nama, deskripsi dan seluruh permission role, role owner tidak bisa diubah
+func (repo *RoleRepository) Update(id int, req models.RoleRequest) (*models.Role, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var name string
+	err = tx.QueryRow("SELECT name FROM roles WHERE id = $1 FOR UPDATE", id).Scan(&name)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if name == models.RoleOwner {
+		return nil, ErrOwnerRoleLocked
+	}
+
+	_, err = tx.Exec("UPDATE roles SET name = $1, description = $2 WHERE id = $3", req.Name, req.Description, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM role_permissions WHERE role_id = $1", id); err != nil {
+		return nil, err
+	}
+	if err := setRolePermissions(tx, id, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// GetByName - nil bila role dengan nama tersebut belum ada
+func (repo *RoleRepository) GetByName(name string) (*models.Role, error) {
+	r, err := scanRole(repo.db.QueryRow("SELECT "+roleColumns+" FROM roles r WHERE r.name = $1", name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+// SetUserRoles - ganti seluruh role pengguna. Ditolak bila nama role tidak dikenal
+// atau bila perubahan membuat toko tidak punya owner aktif.
+func (repo *RoleRepository) SetUserRoles(userID int, roleNames []string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow("SELECT id FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM user_roles WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+	if err := insertUserRoles(tx, userID, roleNames); err != nil {
+		return err
+	}
+
+	if err := ensureOwnerRemains(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertUserRoles - tambahkan role ke pengguna di dalam tx, nama role yang tidak dikenal menjadi ValidationError
+func insertUserRoles(tx *sql.Tx, userID int, roleNames []string) error {
+	roleIDs := make([]int, 0, len(roleNames))
+	unknown := make([]string, 0)
+	for _, name := range roleNames {
+		var roleID int
+		err := tx.QueryRow("SELECT id FROM roles WHERE name = $1", name).Scan(&roleID)
+		if err == sql.ErrNoRows {
+			unknown = append(unknown, name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		roleIDs = append(roleIDs, roleID)
+	}
+	if len(unknown) > 0 {
+		return &models.ValidationError{Message: fmt.Sprintf("role tidak dikenal: %s", strings.Join(unknown, ", "))}
+	}
+
+	for _, roleID := range roleIDs {
+		_, err := tx.Exec("INSERT INTO user_roles (user_id, role_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", userID, roleID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRolePermissions - simpan permission role, kode yang tidak dikenal menjadi ValidationError
+func setRolePermissions(tx *sql.Tx, roleID int, permissions []string) error {
+	var unknown []string
+	err := tx.QueryRow("SELECT ARRAY(SELECT code FROM UNNEST($1::text[]) AS code WHERE code NOT IN (SELECT code FROM permissions))",
+		pq.Array(permissions)).Scan(pq.Array(&unknown))
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return &models.ValidationError{Message: fmt.Sprintf("permission tidak dikenal: %s", strings.Join(unknown, ", "))}
+	}
+
+	_, err = tx.Exec("INSERT INTO role_permissions (role_id, permission_code) SELECT $1, UNNEST($2::text[]) ON CONFLICT DO NOTHING",
+		roleID, pq.Array(permissions))
+	return err
+}
+
+// ensureOwnerRemains - dipanggil di akhir perubahan role / status pengguna, masih di dalam transaksi.
+// Baris role owner dikunci lebih dulu agar dua perubahan pada owner yang berbeda tidak menghitung
+// bersamaan dan sama-sama melihat owner lainnya masih ada.
+func ensureOwnerRemains(tx *sql.Tx) error {
+	var ownerRoleID int
+	err := tx.QueryRow("SELECT id FROM roles WHERE name = $1 FOR UPDATE", models.RoleOwner).Scan(&ownerRoleID)
+	if err == sql.ErrNoRows {
+		return ErrLastOwner
+	}
+	if err != nil {
+		return err
+	}
+
+	var owners int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM user_roles ur
+		INNER JOIN roles r ON ur.role_id = r.id
+		INNER JOIN users u ON ur.user_id = u.id
+		WHERE r.name = $1 AND u.active`, models.RoleOwner).Scan(&owners)
+	if err != nil {
+		return err
+	}
+	if owners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+)
+
+// ErrShiftNotFound - shift dengan id tersebut tidak ada
+var ErrShiftNotFound = errors.New("shift tidak ditemukan")
+
+// ErrShiftAlreadyOpen - masih ada shift open yang belum ditutup
+var ErrShiftAlreadyOpen = errors.New("masih ada shift yang belum ditutup")
+
+// ErrShiftClosed - shift sudah ditutup sehingga tidak bisa diubah lagi
+var ErrShiftClosed = errors.New("shift sudah ditutup")
+
+// ErrNoOpenShift - tidak ada shift open, dipakai juga untuk menolak checkout bila shift diwajibkan
+var ErrNoOpenShift = errors.New("tidak ada shift yang sedang open")
+
+type ShiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+	return &ShiftRepository{db: db}
+}
+
+// queryer - *sql.DB atau *sql.Tx, laporan shift dihitung di dalam transaksi saat shift ditutup
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+const shiftColumns = "id, cashier_name, opening_float, status, counted_cash, expected_cash, close_note, opened_at, closed_at"
+
+func scanShift(row interface{ Scan(...interface{}) error }) (*models.Shift, error) {
+	var s models.Shift
+	err := row.Scan(&s.ID, &s.CashierName, &s.OpeningFloat, &s.Status, &s.CountedCash, &s.ExpectedCash, &s.CloseNote, &s.OpenedAt, &s.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Open - buka shift baru, ditolak bila masih ada shift open
+func (repo *ShiftRepository) Open(req models.OpenShiftRequest) (*models.Shift, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var openID int
+	err = tx.QueryRow("SELECT id FROM shifts WHERE status = $1 FOR UPDATE", models.ShiftStatusOpen).Scan(&openID)
+	if err == nil {
+		return nil, ErrShiftAlreadyOpen
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	shift, err := scanShift(tx.QueryRow("INSERT INTO shifts (cashier_name, opening_float, status) VALUES ($1, $2, $3) RETURNING "+shiftColumns,
+		req.CashierName, req.OpeningFloat, models.ShiftStatusOpen))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// GetAll - daftar shift, terbaru lebih dulu
+func (repo *ShiftRepository) GetAll() ([]models.Shift, error) {
+	rows, err := repo.db.Query("SELECT " + shiftColumns + " FROM shifts ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := make([]models.Shift, 0)
+	for rows.Next() {
+		s, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, *s)
+	}
+
+	return shifts, rows.Err()
+}
+
+func (repo *ShiftRepository) GetByID(id int) (*models.Shift, error) {
+	s, err := scanShift(repo.db.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrShiftNotFound
+	}
+	return s, err
+}
+
+// GetCurrent - shift yang sedang open
+func (repo *ShiftRepository) GetCurrent() (*models.Shift, error) {
+	s, err := scanShift(repo.db.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE status = $1", models.ShiftStatusOpen))
+	if err == sql.ErrNoRows {
+		return nil, ErrNoOpenShift
+	}
+	return s, err
+}
+
+// AddCashMovement - catat kas masuk / keluar pada shift yang masih open
+func (repo *ShiftRepository) AddCashMovement(shiftID int, req models.CashMovementRequest) (*models.CashMovement, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM shifts WHERE id = $1 FOR SHARE", shiftID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil, ErrShiftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status != models.ShiftStatusOpen {
+		return nil, ErrShiftClosed
+	}
+
+	m := models.CashMovement{ShiftID: shiftID, Type: req.Type, Amount: req.Amount, Reason: req.Reason}
+	err = tx.QueryRow("INSERT INTO cash_movements (shift_id, type, amount, reason) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		shiftID, req.Type, req.Amount, req.Reason).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetReport - laporan shift, untuk shift yang sudah ditutup expected cash memakai nilai saat ditutup
+func (repo *ShiftRepository) GetReport(id int) (*models.ShiftReport, error) {
+	shift, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return buildShiftReport(repo.db, shift)
+}
+
+// Close - tutup shift dengan uang fisik yang dihitung kasir. Shift dikunci FOR UPDATE sehingga
+// checkout yang sedang berjalan (memegang FOR SHARE) selesai lebih dulu dan masuk ke laporan.
+func (repo *ShiftRepository) Close(id int, req models.CloseShiftRequest) (*models.ShiftReport, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	shift, err := scanShift(tx.QueryRow("SELECT "+shiftColumns+" FROM shifts WHERE id = $1 FOR UPDATE", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrShiftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if shift.Status != models.ShiftStatusOpen {
+		return nil, ErrShiftClosed
+	}
+
+	report, err := buildShiftReport(tx, shift)
+	if err != nil {
+		return nil, err
+	}
+
+	closed, err := scanShift(tx.QueryRow(`UPDATE shifts SET status = $1, counted_cash = $2, expected_cash = $3, close_note = $4, closed_at = CURRENT_TIMESTAMP
+		WHERE id = $5 RETURNING `+shiftColumns, models.ShiftStatusClosed, *req.CountedCash, report.ExpectedCash, req.Note, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	report.Shift = *closed
+	difference := *req.CountedCash - report.ExpectedCash
+	report.ActualCash = req.CountedCash
+	report.Difference = &difference
+	return report, nil
+}
+
+// buildShiftReport - hitung penjualan per metode dan posisi kas laci dari dokumen yang tercatat pada shift.
+// Pembayaran transaksi yang kemudian di-void tetap dihitung karena uangnya keluar lewat refund.
+func buildShiftReport(q queryer, shift *models.Shift) (*models.ShiftReport, error) {
+	report := models.ShiftReport{
+		Shift:          *shift,
+		PaymentMethods: make([]models.PaymentMethodSummary, 0),
+		CashMovements:  make([]models.CashMovement, 0),
+	}
+
+	err := q.QueryRow("SELECT COUNT(*) FROM transactions WHERE shift_id = $1 AND status IN ($2, $3, $4, $5)", shift.ID,
+		models.TransactionStatusCompleted, models.TransactionStatusPartiallyRefunded, models.TransactionStatusRefunded, models.TransactionStatusVoided).
+		Scan(&report.TotalTransactions)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`
+		SELECT p.method, COALESCE(SUM(p.amount), 0), COUNT(DISTINCT p.transaction_id)
+		FROM payments p
+		INNER JOIN transactions t ON p.transaction_id = t.id
+		WHERE t.shift_id = $1 AND p.status = $2 AND t.status IN ($3, $4, $5, $6)
+		GROUP BY p.method
+		ORDER BY p.method
+	`, shift.ID, models.PaymentStatusPaid,
+		models.TransactionStatusCompleted, models.TransactionStatusPartiallyRefunded, models.TransactionStatusRefunded, models.TransactionStatusVoided)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var p models.PaymentMethodSummary
+		if err := rows.Scan(&p.Method, &p.Amount, &p.Count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if p.Method == models.PaymentMethodCash {
+			report.CashSales = p.Amount
+		}
+		report.PaymentMethods = append(report.PaymentMethods, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query("SELECT id, shift_id, type, amount, reason, created_at FROM cash_movements WHERE shift_id = $1 ORDER BY id", shift.ID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var m models.CashMovement
+		if err := rows.Scan(&m.ID, &m.ShiftID, &m.Type, &m.Amount, &m.Reason, &m.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if m.Type == models.CashMovementIn {
+			report.CashIn += m.Amount
+		} else {
+			report.CashOut += m.Amount
+		}
+		report.CashMovements = append(report.CashMovements, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// hanya bagian refund yang dikembalikan tunai yang keluar dari laci, bukan bagian kasbon atau tender lain
+	err = q.QueryRow(`SELECT COALESCE(SUM(rp.amount), 0)
+		FROM refund_payments rp
+		INNER JOIN refunds r ON rp.refund_id = r.id
+		WHERE r.shift_id = $1 AND rp.method = $2`, shift.ID, models.PaymentMethodCash).Scan(&report.CashRefunds)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM debt_repayments WHERE shift_id = $1 AND method = $2", shift.ID, models.PaymentMethodCash).
+		Scan(&report.DebtRepayments)
+	if err != nil {
+		return nil, err
+	}
+
+	report.ExpectedCash = shift.OpeningFloat + report.CashSales + report.CashIn - report.CashOut - report.CashRefunds + report.DebtRepayments
+	if shift.ExpectedCash != nil {
+		report.ExpectedCash = *shift.ExpectedCash
+	}
+	if shift.CountedCash != nil {
+		difference := *shift.CountedCash - report.ExpectedCash
+		report.ActualCash = shift.CountedCash
+		report.Difference = &difference
+	}
+
+	return &report, nil
+}
+
+// currentShiftID - shift open untuk dokumen yang dibuat di dalam tx, nil bila tidak ada shift open.
+// FOR SHARE menahan penutupan shift sampai dokumen selesai disimpan.
+func currentShiftID(tx *sql.Tx) (*int, error) {
+	var id int
+	err := tx.QueryRow("SELECT id FROM shifts WHERE status = $1 FOR SHARE", models.ShiftStatusOpen).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
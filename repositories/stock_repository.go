@@ -0,0 +1,351 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// ErrProductNotFound - produk dengan id tersebut tidak ada
+var ErrProductNotFound = errors.New("produk tidak ditemukan")
+
+type StockRepository struct {
+	db *sql.DB
+}
+
+func NewStockRepository(db *sql.DB) *StockRepository {
+	return &StockRepository{db: db}
+}
+
+// GetHistory - kartu stok produk, movement terbaru lebih dulu
+func (repo *StockRepository) GetHistory(productID int, filter models.StockMovementFilter) (*models.StockMovementHistory, error) {
+	history := models.StockMovementHistory{ProductID: productID}
+	err := repo.db.QueryRow("SELECT name, stock FROM products WHERE id = $1", productID).Scan(&history.ProductName, &history.Stock)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"m.product_id = $1"}
+	args := []interface{}{productID}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Type != "" {
+		addCondition("m.type = $%d", filter.Type)
+	}
+	if filter.StartDate != nil {
+		addCondition("m.created_at >= $%d", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		addCondition("m.created_at < $%d", *filter.EndDate)
+	}
+
+	rows, err := repo.db.Query(`SELECT m.id, m.product_id, m.type, m.quantity, m.balance_after, m.reference_type, m.reference_id, m.note, m.user_id, u.username, m.created_at
+		FROM stock_movements m LEFT JOIN users u ON m.user_id = u.id
+		WHERE `+strings.Join(conditions, " AND ")+` ORDER BY m.id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history.Movements = make([]models.StockMovement, 0)
+	for rows.Next() {
+		var m models.StockMovement
+		err := rows.Scan(&m.ID, &m.ProductID, &m.Type, &m.Quantity, &m.BalanceAfter, &m.ReferenceType, &m.ReferenceID, &m.Note, &m.UserID, &m.Username, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		history.Movements = append(history.Movements, m)
+	}
+
+	return &history, rows.Err()
+}
+
+// ErrStockAdjustmentNotFound - penyesuaian stok dengan id tersebut tidak ada
+var ErrStockAdjustmentNotFound = errors.New("penyesuaian stok tidak ditemukan")
+
+// CreateAdjustment - terapkan seluruh baris penyesuaian secara atomik. Produk dikunci dalam urutan id,
+// baris yang membuat stok negatif atau merujuk produk yang tidak ada menggagalkan seluruh penyesuaian.
+func (repo *StockRepository) CreateAdjustment(req models.StockAdjustmentRequest) (*models.StockAdjustment, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	productIDs := make([]int, 0, len(req.Items))
+	for _, item := range req.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	products, err := lockStockProducts(tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[int]int)
+	for id, p := range products {
+		balances[id] = p.Stock
+	}
+	itemErrors := make([]models.ItemError, 0)
+	for i, item := range req.Items {
+		if _, ok := products[item.ProductID]; !ok {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "product_id", Reason: fmt.Sprintf("produk %d tidak ditemukan", item.ProductID)})
+			continue
+		}
+		balances[item.ProductID] += item.Quantity
+		if balances[item.ProductID] < 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: fmt.Sprintf("stok tidak cukup, tersedia %d", balances[item.ProductID]-item.Quantity)})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "penyesuaian stok tidak valid", Items: itemErrors}
+	}
+
+	adjustment := models.StockAdjustment{UserID: req.UserID, Items: make([]models.StockAdjustmentItem, 0, len(req.Items))}
+	if req.Note != "" {
+		adjustment.Note = &req.Note
+	}
+	err = tx.QueryRow("INSERT INTO stock_adjustments (note, user_id) VALUES ($1, $2) RETURNING id, created_at",
+		adjustment.Note, adjustment.UserID).Scan(&adjustment.ID, &adjustment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		note := item.Reason
+		if req.Note != "" {
+			note += " - " + req.Note
+		}
+		movement := models.StockMovement{
+			ProductID:     item.ProductID,
+			Type:          models.StockMovementAdjustment,
+			Quantity:      item.Quantity,
+			ReferenceType: models.StockReferenceAdjustment,
+			ReferenceID:   &adjustment.ID,
+			Note:          &note,
+			UserID:        req.UserID,
+		}
+		if err := recordStockMovement(tx, &movement); err != nil {
+			return nil, err
+		}
+
+		line := models.StockAdjustmentItem{
+			ProductID:       item.ProductID,
+			ProductName:     products[item.ProductID].Name,
+			Quantity:        item.Quantity,
+			Reason:          item.Reason,
+			BalanceAfter:    movement.BalanceAfter,
+			StockMovementID: movement.ID,
+		}
+		err := tx.QueryRow(`INSERT INTO stock_adjustment_items (adjustment_id, product_id, product_name, quantity, reason, stock_movement_id)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			adjustment.ID, line.ProductID, line.ProductName, line.Quantity, line.Reason, line.StockMovementID).Scan(&line.ID)
+		if err != nil {
+			return nil, err
+		}
+		adjustment.Items = append(adjustment.Items, line)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+// GetAdjustments - satu halaman penyesuaian stok terbaru lebih dulu beserta barisnya dan jumlah seluruh data
+func (repo *StockRepository) GetAdjustments(filter models.StockAdjustmentFilter) ([]models.StockAdjustment, int, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	if filter.StartDate != nil {
+		args = append(args, *filter.StartDate)
+		conditions = append(conditions, fmt.Sprintf("a.created_at >= $%d", len(args)))
+	}
+	if filter.EndDate != nil {
+		args = append(args, *filter.EndDate)
+		conditions = append(conditions, fmt.Sprintf("a.created_at < $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM stock_adjustments a"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf(`SELECT a.id, a.note, a.user_id, u.username, a.created_at
+		FROM stock_adjustments a LEFT JOIN users u ON a.user_id = u.id%s ORDER BY a.id DESC LIMIT $%d OFFSET $%d`,
+		where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	adjustments := make([]models.StockAdjustment, 0)
+	ids := make([]int, 0)
+	for rows.Next() {
+		var a models.StockAdjustment
+		if err := rows.Scan(&a.ID, &a.Note, &a.UserID, &a.Username, &a.CreatedAt); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		adjustments = append(adjustments, a)
+		ids = append(ids, a.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	items, err := repo.getAdjustmentItems(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range adjustments {
+		adjustments[i].Items = items[adjustments[i].ID]
+		if adjustments[i].Items == nil {
+			adjustments[i].Items = make([]models.StockAdjustmentItem, 0)
+		}
+	}
+	return adjustments, total, nil
+}
+
+func (repo *StockRepository) GetAdjustment(id int) (*models.StockAdjustment, error) {
+	var a models.StockAdjustment
+	err := repo.db.QueryRow(`SELECT a.id, a.note, a.user_id, u.username, a.created_at
+		FROM stock_adjustments a LEFT JOIN users u ON a.user_id = u.id WHERE a.id = $1`, id).
+		Scan(&a.ID, &a.Note, &a.UserID, &a.Username, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrStockAdjustmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := repo.getAdjustmentItems([]int{a.ID})
+	if err != nil {
+		return nil, err
+	}
+	a.Items = items[a.ID]
+	if a.Items == nil {
+		a.Items = make([]models.StockAdjustmentItem, 0)
+	}
+	return &a, nil
+}
+
+// getAdjustmentItems - baris beberapa penyesuaian stok sekaligus dalam satu query, dikelompokkan per adjustment_id
+func (repo *StockRepository) getAdjustmentItems(adjustmentIDs []int) (map[int][]models.StockAdjustmentItem, error) {
+	items := make(map[int][]models.StockAdjustmentItem)
+	if len(adjustmentIDs) == 0 {
+		return items, nil
+	}
+
+	rows, err := repo.db.Query(`SELECT i.adjustment_id, i.id, i.product_id, i.product_name, i.quantity, i.reason, m.balance_after, i.stock_movement_id
+		FROM stock_adjustment_items i INNER JOIN stock_movements m ON i.stock_movement_id = m.id
+		WHERE i.adjustment_id = ANY($1) ORDER BY i.id`, pq.Array(adjustmentIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var adjustmentID int
+		var item models.StockAdjustmentItem
+		err := rows.Scan(&adjustmentID, &item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.Reason, &item.BalanceAfter, &item.StockMovementID)
+		if err != nil {
+			return nil, err
+		}
+		items[adjustmentID] = append(items[adjustmentID], item)
+	}
+	return items, rows.Err()
+}
+
+// stockProduct - nama, saldo stok dan harga pokok produk yang sudah dikunci
+type stockProduct struct {
+	Name      string
+	Stock     int
+	CostPrice int
+}
+
+// lockStockProducts - kunci baris produk dalam urutan id (sama seperti checkout) agar tidak deadlock,
+// produk yang tidak ada tidak muncul di hasil
+func lockStockProducts(tx *sql.Tx, productIDs []int) (map[int]stockProduct, error) {
+	rows, err := tx.Query("SELECT id, name, stock, cost_price FROM products WHERE id = ANY($1) ORDER BY id FOR UPDATE", pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make(map[int]stockProduct)
+	for rows.Next() {
+		var id int
+		var p stockProduct
+		if err := rows.Scan(&id, &p.Name, &p.Stock, &p.CostPrice); err != nil {
+			return nil, err
+		}
+		products[id] = p
+	}
+	return products, rows.Err()
+}
+
+// applyStockMovement - ubah saldo products.stock sebesar m.Quantity dan isi m.BalanceAfter, movement-nya
+// belum disimpan. expectedStock != nil dipakai checkout optimistic: ErrStockConflict bila stok sudah berubah.
+func applyStockMovement(tx *sql.Tx, m *models.StockMovement, expectedStock *int) error {
+	query := "UPDATE products SET stock = stock + $1 WHERE id = $2 RETURNING stock"
+	args := []interface{}{m.Quantity, m.ProductID}
+	if expectedStock != nil {
+		query = "UPDATE products SET stock = stock + $1 WHERE id = $2 AND stock = $3 RETURNING stock"
+		args = append(args, *expectedStock)
+	}
+
+	err := tx.QueryRow(query, args...).Scan(&m.BalanceAfter)
+	if err == sql.ErrNoRows && expectedStock != nil {
+		return ErrStockConflict
+	}
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+// insertStockMovements - simpan movement yang saldonya sudah diterapkan applyStockMovement
+func insertStockMovements(tx *sql.Tx, movements []models.StockMovement) error {
+	for i := range movements {
+		m := &movements[i]
+		err := tx.QueryRow(`INSERT INTO stock_movements (product_id, type, quantity, balance_after, reference_type, reference_id, note, user_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`,
+			m.ProductID, m.Type, m.Quantity, m.BalanceAfter, m.ReferenceType, m.ReferenceID, m.Note, m.UserID).Scan(&m.ID, &m.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordStockMovement - ubah stok dan catat movement-nya di dalam transaksi database yang sama,
+// satu-satunya jalan untuk mengubah products.stock selain checkout
+func recordStockMovement(tx *sql.Tx, m *models.StockMovement) error {
+	if m.Quantity == 0 {
+		return nil
+	}
+	if err := applyStockMovement(tx, m, nil); err != nil {
+		return err
+	}
+	movements := []models.StockMovement{*m}
+	if err := insertStockMovements(tx, movements); err != nil {
+		return err
+	}
+	*m = movements[0]
+	return nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+)
+
+// ErrSupplierNotFound - supplier dengan id tersebut tidak ada
+var ErrSupplierNotFound = errors.New("supplier tidak ditemukan")
+
+type SupplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+const supplierColumns = "id, name, contact_name, phone, email, address, payment_term_days, active, created_at"
+
+func scanSupplier(row interface{ Scan(...interface{}) error }) (*models.Supplier, error) {
+	var s models.Supplier
+	err := row.Scan(&s.ID, &s.Name, &s.ContactName, &s.Phone, &s.Email, &s.Address, &s.PaymentTermDays, &s.Active, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetAll - daftar supplier urut nama, search mencari di nama dan nama kontak
+func (repo *SupplierRepository) GetAll(search string) ([]models.Supplier, error) {
+	query := "SELECT " + supplierColumns + " FROM suppliers"
+	var args []interface{}
+	if search != "" {
+		query += " WHERE name ILIKE $1 OR contact_name ILIKE $1"
+		args = append(args, "%"+search+"%")
+	}
+
+	rows, err := repo.db.Query(query+" ORDER BY name, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]models.Supplier, 0)
+	for rows.Next() {
+		s, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, *s)
+	}
+
+	return suppliers, rows.Err()
+}
+
+func (repo *SupplierRepository) GetByID(id int) (*models.Supplier, error) {
+	s, err := scanSupplier(repo.db.QueryRow("SELECT "+supplierColumns+" FROM suppliers WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrSupplierNotFound
+	}
+	return s, err
+}
+
+// GetByName - nil bila belum ada supplier dengan nama tersebut (tidak membedakan huruf besar / kecil)
+func (repo *SupplierRepository) GetByName(name string) (*models.Supplier, error) {
+	s, err := scanSupplier(repo.db.QueryRow("SELECT "+supplierColumns+" FROM suppliers WHERE LOWER(name) = LOWER($1)", name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (repo *SupplierRepository) Create(req models.SupplierRequest) (*models.Supplier, error) {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	return scanSupplier(repo.db.QueryRow(`INSERT INTO suppliers (name, contact_name, phone, email, address, payment_term_days, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING `+supplierColumns,
+		req.Name, req.ContactName, req.Phone, req.Email, req.Address, req.PaymentTermDays, active))
+}
+
+func (repo *SupplierRepository) Update(id int, req models.SupplierRequest) (*models.Supplier, error) {
+	s, err := scanSupplier(repo.db.QueryRow(`UPDATE suppliers SET name = $1, contact_name = $2, phone = $3, email = $4, address = $5,
+			payment_term_days = $6, active = COALESCE($7, active)
+		WHERE id = $8 RETURNING `+supplierColumns,
+		req.Name, req.ContactName, req.Phone, req.Email, req.Address, req.PaymentTermDays, req.Active, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrSupplierNotFound
+	}
+	return s, err
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+)
+
+type TaxClassRepository struct {
+	db *sql.DB
+}
+
+func NewTaxClassRepository(db *sql.DB) *TaxClassRepository {
+	return &TaxClassRepository{db: db}
+}
+
+func (repo *TaxClassRepository) GetAll() ([]models.TaxClass, error) {
+	rows, err := repo.db.Query("SELECT id, name, rate, price_includes_tax FROM tax_classes ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxClasses := make([]models.TaxClass, 0)
+	for rows.Next() {
+		var t models.TaxClass
+		err := rows.Scan(&t.ID, &t.Name, &t.Rate, &t.PriceIncludesTax)
+		if err != nil {
+			return nil, err
+		}
+		taxClasses = append(taxClasses, t)
+	}
+
+	return taxClasses, nil
+}
+
+func (repo *TaxClassRepository) Create(taxClass *models.TaxClass) error {
+	query := "INSERT INTO tax_classes (name, rate, price_includes_tax) VALUES ($1, $2, $3) RETURNING id"
+	return repo.db.QueryRow(query, taxClass.Name, taxClass.Rate, taxClass.PriceIncludesTax).Scan(&taxClass.ID)
+}
+
+func (repo *TaxClassRepository) GetByID(id int) (*models.TaxClass, error) {
+	var t models.TaxClass
+	err := repo.db.QueryRow("SELECT id, name, rate, price_includes_tax FROM tax_classes WHERE id = $1", id).Scan(&t.ID, &t.Name, &t.Rate, &t.PriceIncludesTax)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("kelas pajak tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (repo *TaxClassRepository) Update(taxClass *models.TaxClass) error {
+	query := "UPDATE tax_classes SET name = $1, rate = $2, price_includes_tax = $3 WHERE id = $4"
+	result, err := repo.db.Exec(query, taxClass.Name, taxClass.Rate, taxClass.PriceIncludesTax, taxClass.ID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("kelas pajak tidak ditemukan")
+	}
+
+	return nil
+}
+
+func (repo *TaxClassRepository) Delete(id int) error {
+	result, err := repo.db.Exec("DELETE FROM tax_classes WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("kelas pajak tidak ditemukan")
+	}
+
+	return nil
+}
@@ -2,96 +2,609 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"kasir-api/models"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrTransactionNotFound - transaksi dengan id tersebut tidak ada
+var ErrTransactionNotFound = errors.New("transaksi tidak ditemukan")
+
+// ErrStockConflict - stok berubah oleh transaksi lain selama checkout mode optimistic
+var ErrStockConflict = errors.New("stok produk berubah saat checkout, silakan coba lagi")
+
+// PriceAdjuster - mengubah baris transaksi (misalnya mengisi diskon) di dalam transaksi checkout
+type PriceAdjuster func(trx *models.Transaction) error
+
+// CheckoutCompletion - dokumen lain yang ditandai selesai di dalam transaksi database checkout yang sama,
+// sehingga tidak ada jeda antara transaksi tersimpan dan dokumen tersebut diperbarui
+type CheckoutCompletion struct {
+	// IdempotencyKey - key milik pengguna checkout yang ditandai completed dengan id transaksi
+	IdempotencyKey string
+	// CartID - keranjang finalizing yang dikunci di awal checkout lalu ditandai finalized
+	CartID *int
+}
+
 type TransactionRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	invoice InvoiceSequence
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+// NewTransactionRepository - invoice menentukan format dan periode reset nomor invoice
+func NewTransactionRepository(db *sql.DB, invoice InvoiceSequence) *TransactionRepository {
+	return &TransactionRepository{db: db, invoice: invoice}
 }
 
-func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+// CreateTransaction - membuat transaksi dan mengurangi stok produk.
+// useLock = true mengunci baris produk dengan SELECT ... FOR UPDATE (pessimistic),
+// useLock = false membaca stok tanpa lock dan mengembalikan ErrStockConflict
+// bila stok sudah diubah transaksi lain sebelum update (optimistic).
+// adjust (opsional) dipanggil setelah harga tiap baris terisi dan sebelum total dihitung.
+// completion diterapkan sebelum commit.
+func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem, useLock bool, adjust PriceAdjuster, completion CheckoutCompletion) (*models.Transaction, error) {
 	tx, err := repo.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	totalAmount := 0
-	details := make([]models.TransactionDetail, 0)
+	// hari yang sudah ditutup laporan Z tidak menerima transaksi baru
+	if err := ensureDayOpen(tx, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if completion.CartID != nil {
+		if err := lockFinalizingCart(tx, *completion.CartID); err != nil {
+			return nil, err
+		}
+	}
+
+	// transaksi dicatat pada shift yang sedang open
+	shiftID, err := currentShiftID(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := loadCheckoutProducts(tx, items, useLock)
+	if err != nil {
+		return nil, err
+	}
 
+	// jumlah yang diminta per produk, product_id yang sama bisa muncul lebih dari sekali
+	requested := make(map[int]int)
 	for _, item := range items {
-		var productPrice, stock int
-		var productName string
+		requested[item.ProductID] += item.Quantity
+	}
+
+	productIDs := make([]int, 0, len(requested))
+	for id := range requested {
+		productIDs = append(productIDs, id)
+	}
+	sort.Ints(productIDs)
 
-		err := tx.QueryRow("SELECT name, price, stock FROM products WHERE id = $1", item.ProductID).Scan(&productName, &productPrice, &stock)
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+	shortages := make([]models.StockShortage, 0)
+	for _, id := range productIDs {
+		p := products[id]
+		if requested[id] > p.Stock {
+			shortages = append(shortages, models.StockShortage{
+				ProductID:   p.ID,
+				ProductName: p.Name,
+				Requested:   requested[id],
+				Available:   p.Stock,
+			})
 		}
-		if err != nil {
+	}
+	if len(shortages) > 0 {
+		return nil, &models.InsufficientStockError{Items: shortages}
+	}
+
+	// update stok selalu dalam urutan product_id agar tidak terjadi deadlock,
+	// movement-nya disimpan setelah id transaksi diketahui
+	movements := make([]models.StockMovement, 0, len(productIDs))
+	for _, id := range productIDs {
+		m := models.StockMovement{
+			ProductID:     id,
+			Type:          models.StockMovementSale,
+			Quantity:      -requested[id],
+			ReferenceType: models.StockReferenceTransaction,
+		}
+		var expectedStock *int
+		if !useLock {
+			expectedStock = &products[id].Stock
+		}
+		if err := applyStockMovement(tx, &m, expectedStock); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+
+	details := make([]models.TransactionDetail, 0)
+	for _, item := range items {
+		p := products[item.ProductID]
+
+		detail := models.TransactionDetail{
+			ProductID:   item.ProductID,
+			ProductName: p.Name,
+			CategoryID:  p.CategoryID,
+			UnitPrice:   p.Price,
+			Quantity:    item.Quantity,
+			Subtotal:    p.Price * item.Quantity,
+		}
+		if p.taxClass != nil {
+			taxClassID, taxClassName := p.taxClass.ID, p.taxClass.Name
+			detail.TaxClassID = &taxClassID
+			detail.TaxClassName = &taxClassName
+			detail.TaxRate = p.taxClass.Rate
+			detail.TaxInclusive = p.taxClass.PriceIncludesTax
+		}
+		details = append(details, detail)
+	}
+
+	trx := &models.Transaction{
+		ShiftID: shiftID,
+		Status:  models.TransactionStatusCompleted,
+		Details: details,
+	}
+
+	// diskon, pajak dan pembayaran diisi sebelum transaksi disimpan
+	if adjust != nil {
+		if err := adjust(trx); err != nil {
+			return nil, err
+		}
+	}
+
+	trx.CalculateTotals()
+
+	// nomor invoice diambil paling akhir agar baris counter terkunci sesingkat mungkin
+	trx.InvoiceNumber, err = repo.invoice.Next(tx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow(`INSERT INTO transactions (invoice_number, customer_id, shift_id, user_id, subtotal_amount, discount_amount, tax_amount, total_amount, paid_amount, change_amount, status,
+			points_earned, points_redeemed, points_expire_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at`,
+		trx.InvoiceNumber, trx.CustomerID, trx.ShiftID, trx.UserID, trx.SubtotalAmount, trx.DiscountAmount, trx.TaxAmount, trx.TotalAmount, trx.PaidAmount, trx.ChangeAmount, trx.Status,
+		trx.PointsEarned, trx.PointsRedeemed, trx.PointsExpireAt).Scan(&trx.ID, &trx.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range movements {
+		movements[i].ReferenceID = &trx.ID
+		movements[i].UserID = trx.UserID
+	}
+	if err := insertStockMovements(tx, movements); err != nil {
+		return nil, err
+	}
+
+	if err := insertTransactionDetails(tx, trx); err != nil {
+		return nil, err
+	}
+
+	if err := insertPayments(tx, trx); err != nil {
+		return nil, err
+	}
+
+	if err := insertReceivable(tx, trx); err != nil {
+		return nil, err
+	}
+
+	// poin dipotong saat checkout, poin yang didapat baru dicatat setelah transaksi lunas
+	if err := redeemPoints(tx, trx); err != nil {
+		return nil, err
+	}
+	if err := awardTransactionPoints(tx, trx.ID); err != nil {
+		return nil, err
+	}
+
+	if completion.IdempotencyKey != "" {
+		if err := completeIdempotencyKey(tx, trx.UserID, completion.IdempotencyKey, trx.ID); err != nil {
 			return nil, err
 		}
-  
-		subtotal := productPrice * item.Quantity
-		totalAmount += subtotal
+	}
+	if completion.CartID != nil {
+		if err := finalizeCart(tx, *completion.CartID, trx.ID); err != nil {
+			return nil, err
+		}
+	}
 
-		_, err = tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", item.Quantity, item.ProductID)
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return trx, nil
+}
+
+// insertTransactionDetails - bulk insert baris transaksi lalu simpan diskon per baris
+func insertTransactionDetails(tx *sql.Tx, trx *models.Transaction) error {
+	details := trx.Details
+	if len(details) == 0 {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`INSERT INTO transaction_details (transaction_id, product_id, product_name, unit_price, quantity, subtotal, discount_amount,
+		tax_class_id, tax_class_name, tax_rate, tax_inclusive, tax_amount) VALUES `)
+
+	const columns = 12
+	args := make([]interface{}, 0, len(details)*columns)
+	placeholders := make([]string, 0, len(details))
+
+	for i := range details {
+		details[i].TransactionID = trx.ID
+
+		paramOffset := i * columns
+		params := make([]string, columns)
+		for c := range params {
+			params[c] = fmt.Sprintf("$%d", paramOffset+c+1)
+		}
+		placeholders = append(placeholders, "("+strings.Join(params, ", ")+")")
+
+		d := details[i]
+		args = append(args, trx.ID, d.ProductID, d.ProductName, d.UnitPrice, d.Quantity, d.Subtotal, d.DiscountAmount,
+			d.TaxClassID, d.TaxClassName, d.TaxRate, d.TaxInclusive, d.TaxAmount)
+	}
+
+	sb.WriteString(strings.Join(placeholders, ", "))
+	sb.WriteString(" RETURNING id, product_id")
+
+	// urutan RETURNING pada multi-row insert tidak dijamin, id dicocokkan lewat product_id
+	// (unik per transaksi karena baris checkout sudah digabung per produk)
+	position := make(map[int]int, len(details))
+	for i, d := range details {
+		if _, ok := position[d.ProductID]; ok {
+			return fmt.Errorf("produk %d muncul lebih dari sekali di baris transaksi", d.ProductID)
+		}
+		position[d.ProductID] = i
+	}
+
+	rows, err := tx.Query(sb.String(), args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id, productID int
+		if err := rows.Scan(&id, &productID); err != nil {
+			rows.Close()
+			return err
+		}
+		i, ok := position[productID]
+		if !ok {
+			rows.Close()
+			return fmt.Errorf("baris transaksi untuk produk %d tidak dikenali", productID)
+		}
+		details[i].ID = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range details {
+		for j := range details[i].Discounts {
+			d := &details[i].Discounts[j]
+			err := tx.QueryRow("INSERT INTO transaction_discounts (transaction_id, transaction_detail_id, promotion_id, promotion_name, amount) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+				trx.ID, details[i].ID, d.PromotionID, d.PromotionName, d.Amount).Scan(&d.ID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkoutProduct - produk di keranjang beserta kelas pajak efektifnya (produk, atau kategori bila kosong)
+type checkoutProduct struct {
+	models.Product
+	taxClass *models.TaxClass
+}
+
+// loadCheckoutProducts - ambil produk yang ada di keranjang, diurutkan berdasarkan id.
+// Dengan lock = true baris produk dikunci sampai transaksi selesai.
+func loadCheckoutProducts(tx *sql.Tx, items []models.CheckoutItem, lock bool) (map[int]*checkoutProduct, error) {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, int64(item.ProductID))
+	}
+
+	query := `
+		SELECT p.id, p.name, p.price, p.stock, p.category_id, tc.id, tc.name, tc.rate, tc.price_includes_tax
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.id
+		LEFT JOIN tax_classes tc ON tc.id = COALESCE(p.tax_class_id, c.tax_class_id)
+		WHERE p.id = ANY($1)
+		ORDER BY p.id`
+	if lock {
+		query += " FOR UPDATE OF p"
+	}
+
+	rows, err := tx.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make(map[int]*checkoutProduct)
+	for rows.Next() {
+		var p checkoutProduct
+		var taxClassID sql.NullInt64
+		var taxClassName sql.NullString
+		var taxRate sql.NullFloat64
+		var taxInclusive sql.NullBool
+		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &taxClassID, &taxClassName, &taxRate, &taxInclusive)
 		if err != nil {
 			return nil, err
 		}
+		if taxClassID.Valid {
+			p.taxClass = &models.TaxClass{
+				ID:               int(taxClassID.Int64),
+				Name:             taxClassName.String,
+				Rate:             taxRate.Float64,
+				PriceIncludesTax: taxInclusive.Bool,
+			}
+		}
+		products[p.ID] = &p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	missing := make([]models.ItemError, 0)
+	for _, item := range items {
+		if _, ok := products[item.ProductID]; !ok {
+			missing = append(missing, models.ItemError{
+				Index:     item.Index,
+				ProductID: item.ProductID,
+				Field:     "product_id",
+				Reason:    fmt.Sprintf("product id %d not found", item.ProductID),
+			})
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &models.ValidationError{Message: "item checkout tidak valid", Items: missing}
+	}
 
-		details = append(details, models.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: productName,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
-		})
+	return products, nil
+}
+
+// GetAll - daftar transaksi sesuai filter, terbaru lebih dulu, beserta jumlah total data
+func (repo *TransactionRepository) GetAll(filter models.TransactionFilter) ([]models.Transaction, int, error) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.StartDate != nil {
+		addCondition("t.created_at >= $%d", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		addCondition("t.created_at < $%d", *filter.EndDate)
+	}
+	if filter.MinAmount != nil {
+		addCondition("t.total_amount >= $%d", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		addCondition("t.total_amount <= $%d", *filter.MaxAmount)
+	}
+	if filter.InvoiceNumber != "" {
+		addCondition("t.invoice_number = $%d", filter.InvoiceNumber)
+	}
+	if filter.CustomerID != nil {
+		addCondition("t.customer_id = $%d", *filter.CustomerID)
+	}
+	if filter.ShiftID != nil {
+		addCondition("t.shift_id = $%d", *filter.ShiftID)
+	}
+	if filter.ProductID != nil {
+		addCondition("EXISTS (SELECT 1 FROM transaction_details td WHERE td.transaction_id = t.id AND td.product_id = $%d)", *filter.ProductID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM transactions t"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf("SELECT t.id, t.invoice_number, t.customer_id, c.name, t.shift_id, t.user_id, t.subtotal_amount, t.discount_amount, t.tax_amount, t.total_amount, t.paid_amount, t.change_amount, t.status, t.points_earned, t.points_redeemed, t.points_expire_at, t.created_at FROM transactions t LEFT JOIN customers c ON t.customer_id = c.id%s ORDER BY t.created_at DESC, t.id DESC LIMIT $%d OFFSET $%d",
+		where, len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0)
+	for rows.Next() {
+		var t models.Transaction
+		err := rows.Scan(&t.ID, &t.InvoiceNumber, &t.CustomerID, &t.CustomerName, &t.ShiftID, &t.UserID, &t.SubtotalAmount, &t.DiscountAmount, &t.TaxAmount, &t.TotalAmount, &t.PaidAmount, &t.ChangeAmount, &t.Status,
+			&t.PointsEarned, &t.PointsRedeemed, &t.PointsExpireAt, &t.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, total, rows.Err()
+}
+
+// GetByID - ambil transaksi beserta detail item, nama dan harga produk diambil dari snapshot saat transaksi
+func (repo *TransactionRepository) GetByID(id int) (*models.Transaction, error) {
+	var t models.Transaction
+	err := repo.db.QueryRow(`SELECT t.id, t.invoice_number, t.customer_id, c.name, t.shift_id, t.user_id, t.subtotal_amount, t.discount_amount, t.tax_amount, t.total_amount, t.paid_amount, t.change_amount, t.status,
+			t.points_earned, t.points_redeemed, t.points_expire_at, t.created_at
+		FROM transactions t LEFT JOIN customers c ON t.customer_id = c.id WHERE t.id = $1`, id).
+		Scan(&t.ID, &t.InvoiceNumber, &t.CustomerID, &t.CustomerName, &t.ShiftID, &t.UserID, &t.SubtotalAmount, &t.DiscountAmount, &t.TaxAmount, &t.TotalAmount, &t.PaidAmount, &t.ChangeAmount, &t.Status,
+			&t.PointsEarned, &t.PointsRedeemed, &t.PointsExpireAt, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	var transactionID int
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", totalAmount).Scan(&transactionID)
+	query := `
+		SELECT id, transaction_id, product_id, product_name, unit_price, quantity, subtotal, discount_amount,
+			tax_class_id, tax_class_name, tax_rate, tax_inclusive, tax_amount
+		FROM transaction_details
+		WHERE transaction_id = $1
+		ORDER BY id
+	`
+	rows, err := repo.db.Query(query, id)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	// Bulk insert transaction details
-	if len(details) > 0 {
-		var sb strings.Builder
-		sb.WriteString("INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ")
-		
-		args := make([]interface{}, 0, len(details)*4)
-		placeholders := make([]string, 0, len(details))
-		
-		for i := range details {
-			details[i].TransactionID = transactionID
-			
-			paramOffset := i * 4
-			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d)", 
-				paramOffset+1, paramOffset+2, paramOffset+3, paramOffset+4))
-			
-			args = append(args, transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal)
-		}
-		
-		sb.WriteString(strings.Join(placeholders, ", "))
-		
-		_, err = tx.Exec(sb.String(), args...)
+	t.Details = make([]models.TransactionDetail, 0)
+	for rows.Next() {
+		var d models.TransactionDetail
+		err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.UnitPrice, &d.Quantity, &d.Subtotal, &d.DiscountAmount,
+			&d.TaxClassID, &d.TaxClassName, &d.TaxRate, &d.TaxInclusive, &d.TaxAmount)
 		if err != nil {
 			return nil, err
 		}
+		t.Details = append(t.Details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := repo.loadLineDiscounts(&t); err != nil {
+		return nil, err
+	}
+
+	if err := repo.loadPayments(&t); err != nil {
+		return nil, err
+	}
+
+	if err := repo.loadReceivable(&t); err != nil {
 		return nil, err
 	}
 
-	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		Details:     details,
-	}, nil
-}
\ No newline at end of file
+	return &t, nil
+}
+
+// GetByInvoiceNumber - sama seperti GetByID, dicari berdasarkan nomor invoice
+func (repo *TransactionRepository) GetByInvoiceNumber(invoiceNumber string) (*models.Transaction, error) {
+	var id int
+	err := repo.db.QueryRow("SELECT id FROM transactions WHERE invoice_number = $1", invoiceNumber).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByID(id)
+}
+
+// insertPayments - simpan tender pembayaran transaksi, payment intent (bila ada)
+// dihubungkan ke tender yang masih pending
+func insertPayments(tx *sql.Tx, trx *models.Transaction) error {
+	for i := range trx.Payments {
+		p := &trx.Payments[i]
+		p.TransactionID = trx.ID
+		err := tx.QueryRow("INSERT INTO payments (transaction_id, method, amount, tendered_amount, reference, status) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at",
+			trx.ID, p.Method, p.Amount, p.TenderedAmount, p.Reference, p.Status).Scan(&p.ID, &p.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if trx.PaymentIntent != nil && p.Status == models.PaymentStatusPending {
+			trx.PaymentIntent.PaymentID = p.ID
+		}
+	}
+
+	if intent := trx.PaymentIntent; intent != nil {
+		intent.TransactionID = trx.ID
+		err := tx.QueryRow("INSERT INTO payment_intents (transaction_id, payment_id, gateway, amount, status, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at",
+			trx.ID, intent.PaymentID, intent.Gateway, intent.Amount, intent.Status, intent.ExpiresAt).Scan(&intent.ID, &intent.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadReceivable - isi piutang bila transaksi adalah kasbon
+func (repo *TransactionRepository) loadReceivable(t *models.Transaction) error {
+	var r models.Receivable
+	err := repo.db.QueryRow("SELECT id, debtor_id, transaction_id, amount, paid_amount, credited_amount, status, created_at FROM receivables WHERE transaction_id = $1", t.ID).
+		Scan(&r.ID, &r.DebtorID, &r.TransactionID, &r.Amount, &r.PaidAmount, &r.CreditedAmount, &r.Status, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	r.InvoiceNumber = t.InvoiceNumber
+	r.Outstanding = r.Amount - r.PaidAmount - r.CreditedAmount
+	t.Receivable = &r
+	return nil
+}
+
+// loadPayments - isi tender pembayaran transaksi
+func (repo *TransactionRepository) loadPayments(t *models.Transaction) error {
+	rows, err := repo.db.Query("SELECT id, transaction_id, method, amount, tendered_amount, reference, status, created_at FROM payments WHERE transaction_id = $1 ORDER BY id", t.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	t.Payments = make([]models.Payment, 0)
+	for rows.Next() {
+		var p models.Payment
+		err := rows.Scan(&p.ID, &p.TransactionID, &p.Method, &p.Amount, &p.TenderedAmount, &p.Reference, &p.Status, &p.CreatedAt)
+		if err != nil {
+			return err
+		}
+		t.Payments = append(t.Payments, p)
+	}
+
+	return rows.Err()
+}
+
+// loadLineDiscounts - isi diskon promo untuk setiap baris transaksi
+func (repo *TransactionRepository) loadLineDiscounts(t *models.Transaction) error {
+	rows, err := repo.db.Query("SELECT id, transaction_detail_id, promotion_id, promotion_name, amount FROM transaction_discounts WHERE transaction_id = $1 ORDER BY id", t.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	index := make(map[int]int)
+	for i, d := range t.Details {
+		index[d.ID] = i
+	}
+
+	for rows.Next() {
+		var d models.LineDiscount
+		var detailID int
+		err := rows.Scan(&d.ID, &detailID, &d.PromotionID, &d.PromotionName, &d.Amount)
+		if err != nil {
+			return err
+		}
+		if i, ok := index[detailID]; ok {
+			t.Details[i].Discounts = append(t.Details[i].Discounts, d)
+		}
+	}
+
+	return rows.Err()
+}
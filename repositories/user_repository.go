@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrUserNotFound - pengguna dengan id / username tersebut tidak ada
+var ErrUserNotFound = errors.New("pengguna tidak ditemukan")
+
+// ErrSessionInvalid - sesi login tidak ada, sudah logout atau kedaluwarsa
+var ErrSessionInvalid = errors.New("sesi tidak valid atau sudah berakhir")
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+const userColumns = `u.id, u.username, u.name, u.active, u.created_at, u.password_hash,
+	ARRAY(SELECT r.name FROM user_roles ur INNER JOIN roles r ON ur.role_id = r.id WHERE ur.user_id = u.id ORDER BY r.name)`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Username, &u.Name, &u.Active, &u.CreatedAt, &u.PasswordHash, pq.Array(&u.Roles))
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (repo *UserRepository) GetAll() ([]models.User, error) {
+	rows, err := repo.db.Query("SELECT " + userColumns + " FROM users u ORDER BY u.username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+
+	return users, rows.Err()
+}
+
+func (repo *UserRepository) GetByID(id int) (*models.User, error) {
+	u, err := scanUser(repo.db.QueryRow("SELECT "+userColumns+" FROM users u WHERE u.id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	return u, err
+}
+
+func (repo *UserRepository) GetByUsername(username string) (*models.User, error) {
+	u, err := scanUser(repo.db.QueryRow("SELECT "+userColumns+" FROM users u WHERE u.username = $1", username))
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	return u, err
+}
+
+func (repo *UserRepository) Count() (int, error) {
+	var count int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	return count, err
+}
+
+// Create - simpan pengguna beserta role-nya dalam satu transaksi database, user.PasswordHash harus sudah
+// berisi hash bcrypt. Bila salah satu role tidak dikenal pengguna tidak ikut tersimpan.
+func (repo *UserRepository) Create(user *models.User, roleNames []string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("INSERT INTO users (username, name, password_hash, active) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+		user.Username, user.Name, user.PasswordHash, user.Active).Scan(&user.ID, &user.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := insertUserRoles(tx, user.ID, roleNames); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update - ubah nama, username, password dan status aktif. Pengguna yang dinonaktifkan langsung kehilangan sesinya.
+func (repo *UserRepository) Update(user *models.User) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("UPDATE users SET username = $1, name = $2, password_hash = $3, active = $4 WHERE id = $5 RETURNING created_at",
+		user.Username, user.Name, user.PasswordHash, user.Active, user.ID).Scan(&user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if !user.Active {
+		_, err = tx.Exec("UPDATE auth_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL", user.ID)
+		if err != nil {
+			return err
+		}
+		if err := ensureOwnerRemains(tx); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CreateSession - simpan sesi login baru dengan hash refresh token
+func (repo *UserRepository) CreateSession(userID int, refreshTokenHash string, expiresAt time.Time) (int, error) {
+	var id int
+	err := repo.db.QueryRow("INSERT INTO auth_sessions (user_id, refresh_token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id",
+		userID, refreshTokenHash, expiresAt).Scan(&id)
+	return id, err
+}
+
+// GetSessionUser - pengguna pemilik sesi yang masih aktif beserta seluruh permission dari role-nya,
+// dipakai middleware pada setiap request sehingga perubahan role langsung berlaku
+func (repo *UserRepository) GetSessionUser(sessionID int) (*models.User, error) {
+	u, err := scanUser(repo.db.QueryRow(`SELECT `+userColumns+` FROM auth_sessions s INNER JOIN users u ON s.user_id = u.id
+		WHERE s.id = $1 AND s.revoked_at IS NULL AND s.expires_at > $2 AND u.active`, sessionID, time.Now()))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.db.QueryRow(`SELECT ARRAY(SELECT DISTINCT rp.permission_code FROM user_roles ur
+		INNER JOIN role_permissions rp ON ur.role_id = rp.role_id WHERE ur.user_id = $1 ORDER BY rp.permission_code)`, u.ID).
+		Scan(pq.Array(&u.Permissions))
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// RotateSession - tukar refresh token lama dengan yang baru secara atomik, refresh token lama tidak bisa dipakai lagi
+func (repo *UserRepository) RotateSession(refreshTokenHash, newRefreshTokenHash string, expiresAt time.Time) (int, *models.User, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	var sessionID, userID int
+	err = tx.QueryRow(`UPDATE auth_sessions SET refresh_token_hash = $1, expires_at = $2
+		WHERE refresh_token_hash = $3 AND revoked_at IS NULL AND expires_at > $4 RETURNING id, user_id`,
+		newRefreshTokenHash, expiresAt, refreshTokenHash, time.Now()).Scan(&sessionID, &userID)
+	if err == sql.ErrNoRows {
+		return 0, nil, ErrSessionInvalid
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	user, err := scanUser(tx.QueryRow("SELECT "+userColumns+" FROM users u WHERE u.id = $1 AND u.active", userID))
+	if err == sql.ErrNoRows {
+		return 0, nil, ErrSessionInvalid
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return sessionID, user, nil
+}
+
+// RevokeSession - logout, access token dan refresh token sesi tersebut tidak berlaku lagi
+func (repo *UserRepository) RevokeSession(sessionID int) error {
+	_, err := repo.db.Exec("UPDATE auth_sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL", sessionID)
+	return err
+}
+
+// DeleteExpiredSessions - hapus sesi yang sudah kedaluwarsa atau sudah logout sebelum before
+func (repo *UserRepository) DeleteExpiredSessions(before time.Time) (int64, error) {
+	result, err := repo.db.Exec("DELETE FROM auth_sessions WHERE expires_at < $1 OR revoked_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
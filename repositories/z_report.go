@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"time"
+)
+
+// ErrDaySealed - laporan Z tanggal tersebut sudah dibuat sehingga angkanya tidak boleh berubah lagi
+var ErrDaySealed = errors.New("hari sudah ditutup dengan laporan Z")
+
+// ErrZReportNotFound - belum ada laporan Z untuk tanggal tersebut
+var ErrZReportNotFound = errors.New("laporan Z tidak ditemukan")
+
+// zReportLockClass - namespace advisory lock per tanggal bisnis. Checkout dan refund memegang lock
+// shared, pembuatan laporan Z memegang lock exclusive sehingga keduanya tidak bisa saling menyela.
+const zReportLockClass = 18
+
+func businessDateKey(date time.Time) int {
+	return date.Year()*10000 + int(date.Month())*100 + date.Day()
+}
+
+// ensureDayOpen - tolak dokumen yang mengubah angka tanggal bisnis yang sudah disegel laporan Z
+func ensureDayOpen(tx *sql.Tx, date time.Time) error {
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock_shared($1, $2)", zReportLockClass, businessDateKey(date)); err != nil {
+		return err
+	}
+
+	var sealed bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM z_reports WHERE business_date = $1)", date.Format("2006-01-02")).Scan(&sealed)
+	if err != nil {
+		return err
+	}
+	if sealed {
+		return ErrDaySealed
+	}
+	return nil
+}
+
+// SealDay - buat laporan Z untuk tanggal bisnis date. build dipanggil setelah lock exclusive didapat,
+// sehingga checkout yang sedang berjalan sudah selesai dan checkout baru menunggu lalu ditolak.
+func (repo *ReportRepository) SealDay(date time.Time, build func() (*models.EndOfDayReport, error)) (*models.EndOfDayReport, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1, $2)", zReportLockClass, businessDateKey(date)); err != nil {
+		return nil, err
+	}
+
+	businessDate := date.Format("2006-01-02")
+	var sealed bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM z_reports WHERE business_date = $1)", businessDate).Scan(&sealed)
+	if err != nil {
+		return nil, err
+	}
+	if sealed {
+		return nil, ErrDaySealed
+	}
+
+	report, err := build()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	var id int
+	err = tx.QueryRow("INSERT INTO z_reports (business_date, report, generated_at) VALUES ($1, $2, $3) RETURNING id",
+		businessDate, body, report.GeneratedAt).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	report.ZNumber = &id
+	return report, nil
+}
+
+// GetZReport - laporan Z yang sudah disegel untuk tanggal bisnis date (YYYY-MM-DD)
+func (repo *ReportRepository) GetZReport(date string) (*models.EndOfDayReport, error) {
+	var id int
+	var body []byte
+	err := repo.db.QueryRow("SELECT id, report FROM z_reports WHERE business_date = $1", date).Scan(&id, &body)
+	if err == sql.ErrNoRows {
+		return nil, ErrZReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeZReport(id, body)
+}
+
+// GetZReports - seluruh laporan Z, tanggal terbaru lebih dulu
+func (repo *ReportRepository) GetZReports() ([]models.EndOfDayReport, error) {
+	rows, err := repo.db.Query("SELECT id, report FROM z_reports ORDER BY business_date DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]models.EndOfDayReport, 0)
+	for rows.Next() {
+		var id int
+		var body []byte
+		if err := rows.Scan(&id, &body); err != nil {
+			return nil, err
+		}
+		report, err := decodeZReport(id, body)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+
+	return reports, rows.Err()
+}
+
+func decodeZReport(id int, body []byte) (*models.EndOfDayReport, error) {
+	var report models.EndOfDayReport
+	if err := json.Unmarshal(body, &report); err != nil {
+		return nil, fmt.Errorf("laporan Z %d rusak: %w", id, err)
+	}
+	report.ZNumber = &id
+	return &report, nil
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidCredentials - username / password salah atau akun nonaktif, sengaja tidak dibedakan
+var ErrInvalidCredentials = errors.New("username atau password salah")
+
+// ErrInvalidToken - access / refresh token tidak valid, kedaluwarsa atau sudah dicabut
+var ErrInvalidToken = errors.New("token tidak valid atau sudah kedaluwarsa")
+
+// ErrUsernameTaken - username sudah dipakai pengguna lain
+var ErrUsernameTaken = errors.New("username sudah dipakai")
+
+const minPasswordLength = 8
+
+// dummyPasswordHash - dibandingkan saat username tidak ditemukan agar waktu respons login sama
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("kasir-dummy-password"), bcrypt.DefaultCost)
+
+// TokenConfig - AccessTTL sebaiknya pendek (menit), RefreshTTL menentukan berapa lama sesi login bertahan
+type TokenConfig struct {
+	Secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// accessClaims - sub berisi id pengguna, sid id sesi login untuk pengecekan logout
+type accessClaims struct {
+	SessionID int `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+type AuthService struct {
+	repo     *repositories.UserRepository
+	roleRepo *repositories.RoleRepository
+	tokens   TokenConfig
+}
+
+func NewAuthService(repo *repositories.UserRepository, roleRepo *repositories.RoleRepository, tokens TokenConfig) *AuthService {
+	return &AuthService{repo: repo, roleRepo: roleRepo, tokens: tokens}
+}
+
+// Login - cek password lalu buat sesi baru beserta access token dan refresh token
+func (s *AuthService) Login(req models.LoginRequest) (*models.TokenResponse, error) {
+	user, err := s.repo.GetByUsername(strings.TrimSpace(req.Username))
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)) != nil || !user.Active {
+		return nil, ErrInvalidCredentials
+	}
+
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := time.Now().Add(s.tokens.RefreshTTL)
+	sessionID, err := s.repo.CreateSession(user.ID, refreshHash, refreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, sessionID, refreshToken, refreshExpiresAt)
+}
+
+// Refresh - tukar refresh token dengan pasangan token baru, refresh token lama langsung tidak berlaku
+func (s *AuthService) Refresh(req models.RefreshRequest) (*models.TokenResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, ErrInvalidToken
+	}
+
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExpiresAt := time.Now().Add(s.tokens.RefreshTTL)
+	sessionID, user, err := s.repo.RotateSession(hashToken(req.RefreshToken), refreshHash, refreshExpiresAt)
+	if errors.Is(err, repositories.ErrSessionInvalid) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, sessionID, refreshToken, refreshExpiresAt)
+}
+
+// Logout - cabut sesi login, access token yang masih berlaku ikut ditolak middleware
+func (s *AuthService) Logout(sessionID int) error {
+	return s.repo.RevokeSession(sessionID)
+}
+
+// Authenticate - verifikasi access token dan pastikan sesinya belum dicabut, mengembalikan pengguna dan id sesi
+func (s *AuthService) Authenticate(accessToken string) (*models.User, int, error) {
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(accessToken, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.tokens.Secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, 0, ErrInvalidToken
+	}
+
+	user, err := s.repo.GetSessionUser(claims.SessionID)
+	if errors.Is(err, repositories.ErrSessionInvalid) {
+		return nil, 0, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if claims.Subject != strconv.Itoa(user.ID) {
+		return nil, 0, ErrInvalidToken
+	}
+
+	return user, claims.SessionID, nil
+}
+
+func (s *AuthService) GetUsers() ([]models.User, error) {
+	return s.repo.GetAll()
+}
+
+func (s *AuthService) GetUser(id int) (*models.User, error) {
+	return s.repo.GetByID(id)
+}
+
+// CreateUser - password wajib diisi minimal 8 karakter dan disimpan sebagai hash bcrypt.
+// Roles opsional, pengguna tanpa role bisa login tetapi tidak punya permission apa pun.
+func (s *AuthService) CreateUser(req models.UserRequest) (*models.User, error) {
+	user := &models.User{Active: true}
+	if req.Active != nil {
+		user.Active = *req.Active
+	}
+	if req.Password == "" {
+		return nil, &models.ValidationError{Message: "password wajib diisi"}
+	}
+	if err := s.applyUserRequest(user, req); err != nil {
+		return nil, err
+	}
+	roles := normalizeRoleNames(req.Roles)
+	for _, name := range roles {
+		role, err := s.roleRepo.GetByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if role == nil {
+			return nil, &models.ValidationError{Message: "role tidak dikenal: " + name}
+		}
+	}
+
+	if err := s.repo.Create(user, roles); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(user.ID)
+}
+
+// UpdateUser - password kosong berarti password lama tetap dipakai
+func (s *AuthService) UpdateUser(id int, req models.UserRequest) (*models.User, error) {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Active != nil {
+		user.Active = *req.Active
+	}
+	if err := s.applyUserRequest(user, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// EnsureInitialUser - buat akun pertama dengan role owner dari konfigurasi bila belum ada pengguna sama sekali
+func (s *AuthService) EnsureInitialUser(username, password string) (bool, error) {
+	count, err := s.repo.Count()
+	if err != nil || count > 0 || username == "" || password == "" {
+		return false, err
+	}
+
+	_, err = s.CreateUser(models.UserRequest{Username: username, Name: username, Password: password, Roles: []string{models.RoleOwner}})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CleanupSessions - hapus sesi yang sudah kedaluwarsa / logout
+func (s *AuthService) CleanupSessions() (int64, error) {
+	return s.repo.DeleteExpiredSessions(time.Now())
+}
+
+// applyUserRequest - validasi dan isi field pengguna dari request, username disimpan huruf kecil
+func (s *AuthService) applyUserRequest(user *models.User, req models.UserRequest) error {
+	user.Username = strings.ToLower(strings.TrimSpace(req.Username))
+	user.Name = strings.TrimSpace(req.Name)
+	if user.Username == "" {
+		return &models.ValidationError{Message: "username wajib diisi"}
+	}
+	if strings.ContainsAny(user.Username, " \t/") {
+		return &models.ValidationError{Message: "username tidak boleh berisi spasi atau '/'"}
+	}
+	if user.Name == "" {
+		user.Name = user.Username
+	}
+
+	existing, err := s.repo.GetByUsername(user.Username)
+	if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+		return err
+	}
+	if existing != nil && existing.ID != user.ID {
+		return ErrUsernameTaken
+	}
+
+	if req.Password != "" {
+		if len(req.Password) < minPasswordLength {
+			return &models.ValidationError{Message: "password minimal 8 karakter"}
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		user.PasswordHash = string(hash)
+	}
+	return nil
+}
+
+// issueTokens - tanda tangani access token JWT (HS256) untuk sesi tersebut
+func (s *AuthService) issueTokens(user *models.User, sessionID int, refreshToken string, refreshExpiresAt time.Time) (*models.TokenResponse, error) {
+	now := time.Now()
+	claims := accessClaims{
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.tokens.AccessTTL)),
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.tokens.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int(s.tokens.AccessTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		User:             *user,
+	}, nil
+}
+
+// newRefreshToken - token acak 32 byte, yang disimpan di database hanya hash-nya
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
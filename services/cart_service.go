@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"time"
+)
+
+// staleFinalizingAge - keranjang finalizing yang lebih lama dari ini dianggap checkout-nya terputus
+const staleFinalizingAge = 10 * time.Minute
+
+type CartService struct {
+	repo               *repositories.CartRepository
+	transactionService *TransactionService
+	ttl                time.Duration
+}
+
+// NewCartService - ttl adalah lama keranjang tanpa aktivitas sebelum dianggap ditinggalkan
+func NewCartService(repo *repositories.CartRepository, transactionService *TransactionService, ttl time.Duration) *CartService {
+	return &CartService{repo: repo, transactionService: transactionService, ttl: ttl}
+}
+
+func (s *CartService) expiresAt() time.Time {
+	return time.Now().Add(s.ttl)
+}
+
+func (s *CartService) Create(req models.CartRequest) (*models.Cart, error) {
+	cart := &models.Cart{
+		Label:     req.Label,
+		Status:    models.CartStatusOpen,
+		ExpiresAt: s.expiresAt(),
+		Items:     make([]models.CartItem, 0),
+	}
+	if err := s.repo.Create(cart); err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (s *CartService) GetAll(status string) ([]models.Cart, error) {
+	return s.repo.GetAll(status)
+}
+
+// GetByID - keranjang dengan harga terkini, total dan peringatan stok per item
+func (s *CartService) GetByID(id int) (*models.Cart, error) {
+	cart, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		item.Subtotal = item.UnitPrice * item.Quantity
+		cart.Total += item.Subtotal
+
+		switch {
+		case item.Stock <= 0:
+			item.StockWarning = "stok habis"
+		case item.Quantity > item.Stock:
+			item.StockWarning = fmt.Sprintf("stok hanya tersisa %d", item.Stock)
+		}
+	}
+
+	return cart, nil
+}
+
+// AddItem - tambah quantity produk di keranjang
+func (s *CartService) AddItem(cartID int, req models.CartItemRequest) (*models.Cart, error) {
+	if err := validateCartQuantity(req.Quantity); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpsertItem(cartID, req.ProductID, req.Quantity, true, s.expiresAt()); err != nil {
+		return nil, err
+	}
+	return s.GetByID(cartID)
+}
+
+// UpdateItem - ganti quantity produk di keranjang
+func (s *CartService) UpdateItem(cartID, productID, quantity int) (*models.Cart, error) {
+	if err := validateCartQuantity(quantity); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpsertItem(cartID, productID, quantity, false, s.expiresAt()); err != nil {
+		return nil, err
+	}
+	return s.GetByID(cartID)
+}
+
+func (s *CartService) RemoveItem(cartID, productID int) (*models.Cart, error) {
+	if err := s.repo.RemoveItem(cartID, productID, s.expiresAt()); err != nil {
+		return nil, err
+	}
+	return s.GetByID(cartID)
+}
+
+// Hold - parkir keranjang agar kasir bisa melayani pelanggan berikutnya
+func (s *CartService) Hold(cartID int) (*models.Cart, error) {
+	if err := s.repo.UpdateStatus(cartID, []string{models.CartStatusOpen}, models.CartStatusHeld, s.expiresAt()); err != nil {
+		return nil, err
+	}
+	return s.GetByID(cartID)
+}
+
+// Resume - buka kembali keranjang yang di-hold
+func (s *CartService) Resume(cartID int) (*models.Cart, error) {
+	if err := s.repo.UpdateStatus(cartID, []string{models.CartStatusHeld}, models.CartStatusOpen, s.expiresAt()); err != nil {
+		return nil, err
+	}
+	return s.GetByID(cartID)
+}
+
+// Finalize - checkout isi keranjang lewat TransactionService.Checkout. Keranjang dikunci dengan
+// status finalizing selama checkout agar tidak di-checkout dua kali dan ditandai finalized di dalam
+// transaksi database checkout. Bila checkout gagal sebelum transaksi tersimpan keranjang kembali open.
+// Bila payment gateway gagal setelah commit, transaksinya expired dan keranjang juga dibuka kembali.
+func (s *CartService) Finalize(cartID int, req models.CartCheckoutRequest, useLock bool) (*models.Transaction, error) {
+	if err := s.repo.UpdateStatus(cartID, []string{models.CartStatusOpen}, models.CartStatusFinalizing, s.expiresAt()); err != nil {
+		return nil, err
+	}
+
+	transaction, err := s.finalize(cartID, req, useLock)
+	if err != nil && transaction != nil {
+		if transaction.Status == models.TransactionStatusExpired {
+			if reopenErr := s.repo.Reopen(cartID, transaction.ID, s.expiresAt()); reopenErr != nil {
+				return nil, reopenErr
+			}
+		}
+		return transaction, err
+	}
+	if err != nil {
+		revertErr := s.repo.UpdateStatus(cartID, []string{models.CartStatusFinalizing}, models.CartStatusOpen, s.expiresAt())
+		if revertErr != nil && !errors.Is(revertErr, repositories.ErrCartStatus) {
+			return nil, revertErr
+		}
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+func (s *CartService) finalize(cartID int, req models.CartCheckoutRequest, useLock bool) (*models.Transaction, error) {
+	cart, err := s.repo.GetByID(cartID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.CheckoutItem, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		items = append(items, models.CheckoutItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	return s.transactionService.Checkout(models.CheckoutRequest{Items: items, Payments: req.Payments, CustomerID: req.CustomerID, MemberPhone: req.MemberPhone, DebtorID: req.DebtorID, UserID: req.UserID, CartID: &cartID}, useLock)
+}
+
+// ExpireAbandoned - kedaluwarsakan keranjang yang tidak disentuh melewati ttl, setelah lebih dulu membuka
+// kembali keranjang yang tertahan finalizing karena checkout-nya terputus sebelum transaksi tersimpan
+func (s *CartService) ExpireAbandoned() (int64, error) {
+	now := time.Now()
+	if _, err := s.repo.RecoverFinalizing(now.Add(-staleFinalizingAge), s.expiresAt()); err != nil {
+		return 0, err
+	}
+	return s.repo.ExpireAbandoned(now)
+}
+
+func validateCartQuantity(quantity int) error {
+	if quantity <= 0 {
+		return &models.ValidationError{Message: "quantity harus lebih dari 0"}
+	}
+	if quantity > MaxLineQuantity {
+		return &models.ValidationError{Message: fmt.Sprintf("quantity maksimal %d per produk", MaxLineQuantity)}
+	}
+	return nil
+}
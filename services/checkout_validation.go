@@ -0,0 +1,80 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+)
+
+const (
+	// MaxCheckoutLines - jumlah maksimal produk berbeda dalam satu checkout
+	MaxCheckoutLines = 100
+	// MaxLineQuantity - quantity maksimal per produk dalam satu checkout
+	MaxLineQuantity = 10000
+)
+
+// ValidateCheckoutItems - validasi item checkout dan gabungkan product_id yang sama menjadi satu baris.
+// Mengembalikan *models.ValidationError yang berisi setiap baris bermasalah beserta alasannya.
+func ValidateCheckoutItems(items []models.CheckoutItem) ([]models.CheckoutItem, error) {
+	if len(items) == 0 {
+		return nil, &models.ValidationError{Message: "items tidak boleh kosong"}
+	}
+
+	itemErrors := make([]models.ItemError, 0)
+	merged := make([]models.CheckoutItem, 0, len(items))
+	// posisi product_id di slice merged beserta index baris pertama di request
+	position := make(map[int]int)
+	firstIndex := make(map[int]int)
+
+	for i, item := range items {
+		valid := true
+		if item.ProductID <= 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "product_id", Reason: "product_id wajib diisi dan harus lebih dari 0"})
+			valid = false
+		}
+		if item.Quantity <= 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: "quantity harus lebih dari 0"})
+			valid = false
+		}
+		// setiap baris dibatasi sebelum digabung agar penjumlahan tidak overflow
+		if item.Quantity > MaxLineQuantity {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: fmt.Sprintf("quantity %d melebihi batas %d per produk", item.Quantity, MaxLineQuantity)})
+			valid = false
+		}
+		if !valid {
+			continue
+		}
+
+		if pos, ok := position[item.ProductID]; ok {
+			// total yang sudah melewati batas tidak dijumlahkan lagi, cukup dilaporkan sekali di bawah
+			if merged[pos].Quantity <= MaxLineQuantity {
+				merged[pos].Quantity += item.Quantity
+			}
+			continue
+		}
+		position[item.ProductID] = len(merged)
+		firstIndex[item.ProductID] = i
+		item.Index = i
+		merged = append(merged, item)
+	}
+
+	for _, item := range merged {
+		if item.Quantity > MaxLineQuantity {
+			itemErrors = append(itemErrors, models.ItemError{
+				Index:     firstIndex[item.ProductID],
+				ProductID: item.ProductID,
+				Field:     "quantity",
+				Reason:    fmt.Sprintf("total quantity melebihi batas %d per produk", MaxLineQuantity),
+			})
+		}
+	}
+
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "item checkout tidak valid", Items: itemErrors}
+	}
+
+	if len(merged) > MaxCheckoutLines {
+		return nil, &models.ValidationError{Message: fmt.Sprintf("jumlah produk dalam satu checkout maksimal %d", MaxCheckoutLines)}
+	}
+
+	return merged, nil
+}
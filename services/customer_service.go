@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+// ErrCustomerPhoneTaken - nomor telepon sudah dipakai pelanggan lain
+var ErrCustomerPhoneTaken = errors.New("nomor telepon sudah terdaftar untuk pelanggan lain")
+
+type CustomerService struct {
+	repo               *repositories.CustomerRepository
+	transactionService *TransactionService
+}
+
+func NewCustomerService(repo *repositories.CustomerRepository, transactionService *TransactionService) *CustomerService {
+	return &CustomerService{repo: repo, transactionService: transactionService}
+}
+
+func (s *CustomerService) GetAll(search string) ([]models.Customer, error) {
+	return s.repo.GetAll(strings.TrimSpace(search))
+}
+
+func (s *CustomerService) Create(customer *models.Customer) error {
+	if err := s.validate(customer); err != nil {
+		return err
+	}
+	return s.repo.Create(customer)
+}
+
+func (s *CustomerService) GetByID(id int) (*models.Customer, error) {
+	return s.repo.GetByID(id)
+}
+
+// GetByPhone - pencarian kasir, format 0812-3456 7890 / +62812... / 62812... dianggap sama
+func (s *CustomerService) GetByPhone(phone string) (*models.Customer, error) {
+	return s.repo.GetByPhone(NormalizePhone(phone))
+}
+
+func (s *CustomerService) Update(customer *models.Customer) error {
+	if err := s.validate(customer); err != nil {
+		return err
+	}
+	return s.repo.Update(customer)
+}
+
+func (s *CustomerService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+// GetTransactions - riwayat pembelian pelanggan dengan filter dan pagination yang sama seperti /api/transactions
+func (s *CustomerService) GetTransactions(customerID int, filter models.TransactionFilter) (*models.TransactionListResponse, error) {
+	if _, err := s.repo.GetByID(customerID); err != nil {
+		return nil, err
+	}
+	filter.CustomerID = &customerID
+	return s.transactionService.GetAll(filter)
+}
+
+// validate - normalisasi field opsional dan pastikan nomor telepon belum dipakai pelanggan lain
+func (s *CustomerService) validate(c *models.Customer) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return &models.ValidationError{Message: "nama pelanggan wajib diisi"}
+	}
+
+	if c.Phone != nil {
+		phone := NormalizePhone(*c.Phone)
+		c.Phone = nil
+		if phone != "" {
+			if len(phone) < 8 || len(phone) > 15 {
+				return &models.ValidationError{Message: "nomor telepon tidak valid"}
+			}
+			c.Phone = &phone
+
+			existing, err := s.repo.GetByPhone(phone)
+			if err != nil && !errors.Is(err, repositories.ErrCustomerNotFound) {
+				return err
+			}
+			if existing != nil && existing.ID != c.ID {
+				return ErrCustomerPhoneTaken
+			}
+		}
+	}
+
+	if c.Email != nil {
+		email := strings.TrimSpace(*c.Email)
+		c.Email = nil
+		if email != "" {
+			if !strings.Contains(email, "@") {
+				return &models.ValidationError{Message: "email tidak valid"}
+			}
+			c.Email = &email
+		}
+	}
+
+	return nil
+}
+
+// NormalizePhone - simpan hanya digit dengan awalan 0, +62 / 62 diganti 0
+func NormalizePhone(phone string) string {
+	var sb strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	digits := sb.String()
+	if strings.HasPrefix(digits, "62") {
+		digits = "0" + strings.TrimPrefix(digits, "62")
+	}
+	return digits
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"time"
+)
+
+// ErrDebtorExists - pelanggan sudah terdaftar sebagai debitur
+var ErrDebtorExists = errors.New("pelanggan sudah terdaftar sebagai debitur")
+
+// metode pembayaran cicilan kasbon, hanya pembayaran langsung di kasir
+var debtRepaymentMethods = map[string]bool{
+	models.PaymentMethodCash:     true,
+	models.PaymentMethodDebit:    true,
+	models.PaymentMethodTransfer: true,
+}
+
+type DebtService struct {
+	repo         *repositories.DebtRepository
+	customerRepo *repositories.CustomerRepository
+}
+
+func NewDebtService(repo *repositories.DebtRepository, customerRepo *repositories.CustomerRepository) *DebtService {
+	return &DebtService{repo: repo, customerRepo: customerRepo}
+}
+
+func (s *DebtService) GetAll(outstandingOnly bool) ([]models.Debtor, error) {
+	return s.repo.GetAll(outstandingOnly)
+}
+
+// Create - daftarkan pelanggan sebagai debitur, satu pelanggan hanya punya satu debitur
+func (s *DebtService) Create(req models.DebtorRequest) (*models.Debtor, error) {
+	if req.CreditLimit < 0 {
+		return nil, &models.ValidationError{Message: "credit_limit tidak boleh negatif"}
+	}
+
+	_, err := s.customerRepo.GetByID(req.CustomerID)
+	if errors.Is(err, repositories.ErrCustomerNotFound) {
+		return nil, &models.ValidationError{Message: "pelanggan tidak ditemukan"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetByCustomerID(req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrDebtorExists
+	}
+
+	return s.repo.Create(req)
+}
+
+func (s *DebtService) GetByID(id int) (*models.Debtor, error) {
+	return s.repo.GetByID(id)
+}
+
+// Update - ubah limit kredit dan status aktif, customer_id tidak bisa diubah
+func (s *DebtService) Update(id int, req models.DebtorRequest) (*models.Debtor, error) {
+	if req.CreditLimit < 0 {
+		return nil, &models.ValidationError{Message: "credit_limit tidak boleh negatif"}
+	}
+	return s.repo.Update(id, req)
+}
+
+func (s *DebtService) GetReceivables(debtorID int, openOnly bool) ([]models.Receivable, error) {
+	if _, err := s.repo.GetByID(debtorID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReceivables(debtorID, openOnly)
+}
+
+// CreateRepayment - cicilan kasbon, dialokasikan ke piutang terlama lebih dulu
+func (s *DebtService) CreateRepayment(debtorID int, req models.DebtRepaymentRequest) (*models.DebtRepayment, error) {
+	if req.Method == "" {
+		req.Method = models.PaymentMethodCash
+	}
+	if !debtRepaymentMethods[req.Method] {
+		return nil, &models.ValidationError{Message: "metode pembayaran cicilan harus cash, debit atau transfer"}
+	}
+	if req.Amount <= 0 {
+		return nil, &models.ValidationError{Message: "amount harus lebih dari 0"}
+	}
+	return s.repo.CreateRepayment(debtorID, req)
+}
+
+func (s *DebtService) GetRepayments(debtorID int) ([]models.DebtRepayment, error) {
+	if _, err := s.repo.GetByID(debtorID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRepayments(debtorID)
+}
+
+// GetAging - umur piutang per hari ini
+func (s *DebtService) GetAging() (*models.DebtAgingReport, error) {
+	return s.repo.GetAging(time.Now())
+}
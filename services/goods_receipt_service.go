@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type GoodsReceiptService struct {
+	repo         *repositories.GoodsReceiptRepository
+	supplierRepo *repositories.SupplierRepository
+}
+
+func NewGoodsReceiptService(repo *repositories.GoodsReceiptRepository, supplierRepo *repositories.SupplierRepository) *GoodsReceiptService {
+	return &GoodsReceiptService{repo: repo, supplierRepo: supplierRepo}
+}
+
+func (s *GoodsReceiptService) GetAll(filter models.GoodsReceiptFilter) ([]models.GoodsReceipt, error) {
+	switch filter.Status {
+	case "", models.GoodsReceiptStatusPosted, models.GoodsReceiptStatusReversed:
+	default:
+		return nil, &models.ValidationError{Message: "status harus posted atau reversed"}
+	}
+	filter.Supplier = strings.TrimSpace(filter.Supplier)
+	return s.repo.GetAll(filter)
+}
+
+func (s *GoodsReceiptService) GetByID(id int) (*models.GoodsReceipt, error) {
+	return s.repo.GetByID(id)
+}
+
+// Create - penerimaan barang langsung di-posting, nama supplier diambil dari data supplier bila supplier_id diisi
+func (s *GoodsReceiptService) Create(req models.GoodsReceiptRequest) (*models.GoodsReceipt, error) {
+	req.SupplierName = strings.TrimSpace(req.SupplierName)
+	req.InvoiceRef = strings.TrimSpace(req.InvoiceRef)
+	req.Note = strings.TrimSpace(req.Note)
+
+	if req.SupplierID != nil {
+		supplier, err := s.supplierRepo.GetByID(*req.SupplierID)
+		if errors.Is(err, repositories.ErrSupplierNotFound) {
+			return nil, &models.ValidationError{Message: "supplier tidak ditemukan"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		req.SupplierName = supplier.Name
+	}
+	if req.SupplierName == "" {
+		return nil, &models.ValidationError{Message: "supplier_id atau supplier_name wajib diisi"}
+	}
+
+	if len(req.Items) == 0 {
+		return nil, &models.ValidationError{Message: "items tidak boleh kosong"}
+	}
+	itemErrors := make([]models.ItemError, 0)
+	for i, item := range req.Items {
+		if item.Quantity <= 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: fmt.Sprintf("quantity harus lebih dari 0, diterima %d", item.Quantity)})
+		}
+		if item.UnitCost < 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "unit_cost", Reason: "unit_cost tidak boleh negatif"})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "penerimaan barang tidak valid", Items: itemErrors}
+	}
+
+	return s.repo.Create(req)
+}
+
+// Reverse - batalkan penerimaan yang sudah di-posting, stok dan harga pokok dikembalikan
+func (s *GoodsReceiptService) Reverse(id int, req models.ReverseGoodsReceiptRequest) (*models.GoodsReceipt, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	return s.repo.Reverse(id, req)
+}
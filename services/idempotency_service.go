@@ -0,0 +1,69 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"time"
+)
+
+// ErrIdempotencyKeyMismatch - key yang sama dipakai untuk body request yang berbeda
+var ErrIdempotencyKeyMismatch = errors.New("Idempotency-Key sudah dipakai untuk request yang berbeda")
+
+// ErrIdempotencyKeyInProgress - request dengan key yang sama masih diproses
+var ErrIdempotencyKeyInProgress = errors.New("request dengan Idempotency-Key ini masih diproses")
+
+// staleProcessingAge - key processing yang lebih lama dari ini dianggap tertinggal dan boleh dihapus
+const staleProcessingAge = 10 * time.Minute
+
+type IdempotencyService struct {
+	repo      *repositories.IdempotencyRepository
+	retention time.Duration
+}
+
+// NewIdempotencyService - retention adalah lama key disimpan sebelum dibersihkan
+func NewIdempotencyService(repo *repositories.IdempotencyRepository, retention time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, retention: retention}
+}
+
+// HashRequest - hash SHA-256 dari body request yang sudah dinormalisasi
+func HashRequest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Begin - key berlaku per pengguna. nil berarti key baru dan request boleh diproses, selain itu record
+// berisi response asli (atau transaksi) yang harus diputar ulang
+func (s *IdempotencyService) Begin(userID *int, key, requestHash string) (*models.IdempotencyRecord, error) {
+	record, reserved, err := s.repo.Reserve(userID, key, requestHash)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+	if record.Status != models.IdempotencyStatusCompleted {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	return record, nil
+}
+
+func (s *IdempotencyService) Complete(userID *int, key string, responseStatus int, responseBody []byte, transactionID *int) error {
+	return s.repo.Complete(userID, key, responseStatus, responseBody, transactionID)
+}
+
+func (s *IdempotencyService) Release(userID *int, key string) error {
+	return s.repo.Release(userID, key)
+}
+
+// Cleanup - hapus key yang melewati masa retensi
+func (s *IdempotencyService) Cleanup() (int64, error) {
+	now := time.Now()
+	return s.repo.DeleteExpired(now.Add(-s.retention), now.Add(-staleProcessingAge))
+}
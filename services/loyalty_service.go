@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"time"
+)
+
+// LoyaltyProgram - aturan poin: 1 poin untuk setiap EarnRupiahPerPoint belanja,
+// 1 poin bernilai PointValue rupiah saat dipakai, poin hangus setelah Validity
+type LoyaltyProgram struct {
+	EarnRupiahPerPoint int
+	PointValue         int
+	Validity           time.Duration
+}
+
+type LoyaltyService struct {
+	repo         *repositories.LoyaltyRepository
+	customerRepo *repositories.CustomerRepository
+	program      LoyaltyProgram
+	lastExpiry   time.Time
+}
+
+func NewLoyaltyService(repo *repositories.LoyaltyRepository, customerRepo *repositories.CustomerRepository, program LoyaltyProgram) *LoyaltyService {
+	return &LoyaltyService{repo: repo, customerRepo: customerRepo, program: program}
+}
+
+// ApplyPoints - hitung poin yang dipakai dari tender "points" dan poin yang didapat dari pembayaran
+// selain poin dan kasbon. Dipanggil setelah SettlePayments / SettlePayLater; saldo poin dicek
+// repository di dalam transaksi checkout.
+func (s *LoyaltyService) ApplyPoints(trx *models.Transaction, member bool) error {
+	redeemedAmount := 0
+	itemErrors := make([]models.ItemError, 0)
+	for i, p := range trx.Payments {
+		if p.Method != models.PaymentMethodPoints {
+			continue
+		}
+		if !member {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, Field: "method", Reason: "pembayaran dengan poin membutuhkan member_phone"})
+			continue
+		}
+		if p.Amount%s.program.PointValue != 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, Field: "amount", Reason: fmt.Sprintf("amount poin harus kelipatan %d", s.program.PointValue)})
+			continue
+		}
+		redeemedAmount += p.Amount
+	}
+	if len(itemErrors) > 0 {
+		return &models.ValidationError{Message: "pembayaran tidak valid", Items: itemErrors}
+	}
+
+	trx.PointsRedeemed = redeemedAmount / s.program.PointValue
+	if !member {
+		return nil
+	}
+
+	// belanja yang dibayar dengan poin atau masih menjadi kasbon tidak menghasilkan poin baru
+	earnBase := trx.TotalAmount - redeemedAmount
+	if trx.Receivable != nil {
+		earnBase -= trx.Receivable.Amount
+	}
+	trx.PointsEarned = earnBase / s.program.EarnRupiahPerPoint
+	if trx.PointsEarned < 0 {
+		trx.PointsEarned = 0
+	}
+	if trx.PointsEarned > 0 {
+		expireAt := time.Now().Add(s.program.Validity)
+		trx.PointsExpireAt = &expireAt
+	}
+	return nil
+}
+
+// GetBalance - saldo poin member berdasarkan nomor telepon
+func (s *LoyaltyService) GetBalance(phone string) (*models.LoyaltyBalance, error) {
+	customer, err := s.member(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.repo.GetBalance(customer.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoyaltyBalance{
+		CustomerID:       customer.ID,
+		CustomerName:     customer.Name,
+		Phone:            customer.Phone,
+		Points:           points,
+		RedeemableAmount: points * s.program.PointValue,
+	}, nil
+}
+
+// GetHistory - riwayat ledger poin member, limit default 50 maksimal 200
+func (s *LoyaltyService) GetHistory(phone string, limit int) ([]models.LoyaltyEntry, error) {
+	customer, err := s.member(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit < 1 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	return s.repo.GetHistory(customer.ID, limit)
+}
+
+// ExpireDue - hanguskan poin yang batas berlakunya lewat sejak pemanggilan sebelumnya
+func (s *LoyaltyService) ExpireDue() (int, error) {
+	now := time.Now()
+	expired, err := s.repo.ExpireDue(s.lastExpiry, now)
+	if err != nil {
+		return 0, err
+	}
+	s.lastExpiry = now
+	return expired, nil
+}
+
+func (s *LoyaltyService) member(phone string) (*models.Customer, error) {
+	return s.customerRepo.GetByPhone(NormalizePhone(phone))
+}
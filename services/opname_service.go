@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type OpnameService struct {
+	repo *repositories.OpnameRepository
+}
+
+func NewOpnameService(repo *repositories.OpnameRepository) *OpnameService {
+	return &OpnameService{repo: repo}
+}
+
+// Create - buka sesi opname dan snapshot stok, category_ids kosong berarti seluruh produk
+func (s *OpnameService) Create(req models.StockOpnameRequest) (*models.StockOpname, error) {
+	req.Note = strings.TrimSpace(req.Note)
+	for _, id := range req.CategoryIDs {
+		if id <= 0 {
+			return nil, &models.ValidationError{Message: "category_ids tidak valid"}
+		}
+	}
+	return s.repo.Create(req)
+}
+
+func (s *OpnameService) GetAll(status string) ([]models.StockOpname, error) {
+	switch status {
+	case "", models.OpnameStatusOpen, models.OpnameStatusApproved, models.OpnameStatusCancelled:
+	default:
+		return nil, &models.ValidationError{Message: "status harus open, approved atau cancelled"}
+	}
+	return s.repo.GetAll(status)
+}
+
+func (s *OpnameService) GetByID(id int) (*models.StockOpname, error) {
+	return s.repo.GetByID(id)
+}
+
+// AddCounts - satu putaran hitungan dari satu penghitung, bisa berisi banyak produk
+func (s *OpnameService) AddCounts(opnameID int, req models.StockOpnameCountRequest) (*models.StockOpname, error) {
+	req.Counter = strings.TrimSpace(req.Counter)
+	if req.Counter == "" {
+		return nil, &models.ValidationError{Message: "counter wajib diisi"}
+	}
+	if len(req.Items) == 0 {
+		return nil, &models.ValidationError{Message: "items tidak boleh kosong"}
+	}
+
+	itemErrors := make([]models.ItemError, 0)
+	for i, item := range req.Items {
+		if item.Quantity < 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: fmt.Sprintf("quantity tidak boleh negatif, diterima %d", item.Quantity)})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "hitungan stock opname tidak valid", Items: itemErrors}
+	}
+
+	return s.repo.AddCounts(opnameID, req)
+}
+
+// Approve - posting seluruh selisih hitungan ke kartu stok secara atomik
+func (s *OpnameService) Approve(opnameID int, req models.ApproveOpnameRequest) (*models.StockOpname, error) {
+	return s.repo.Approve(opnameID, req)
+}
+
+func (s *OpnameService) Cancel(opnameID int) (*models.StockOpname, error) {
+	return s.repo.Cancel(opnameID)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"time"
+)
+
+// ErrInvalidSignature - signature callback payment gateway tidak cocok
+var ErrInvalidSignature = errors.New("signature callback tidak valid")
+
+// PaymentGateway - penyedia pembayaran non-tunai asinkron (QRIS / e-wallet).
+// CreateIntent mengisi ExternalID dan QRString, ParseCallback memverifikasi
+// signature callback lalu mengembalikan status pembayarannya.
+type PaymentGateway interface {
+	Name() string
+	CreateIntent(intent *models.PaymentIntent) error
+	ParseCallback(body []byte, signature string) (*models.PaymentCallback, error)
+}
+
+// FakePaymentGateway - gateway lokal untuk development, callback ditandatangani HMAC-SHA256
+type FakePaymentGateway struct {
+	secret []byte
+}
+
+func NewFakePaymentGateway(secret string) *FakePaymentGateway {
+	return &FakePaymentGateway{secret: []byte(secret)}
+}
+
+// fakeCallbackPayload - format body callback FakePaymentGateway
+type fakeCallbackPayload struct {
+	ExternalID string    `json:"external_id"`
+	Status     string    `json:"status"`
+	PaidAt     time.Time `json:"paid_at"`
+}
+
+func (g *FakePaymentGateway) Name() string {
+	return "fake"
+}
+
+func (g *FakePaymentGateway) CreateIntent(intent *models.PaymentIntent) error {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+
+	externalID := fmt.Sprintf("FAKE-%d-%s", intent.TransactionID, hex.EncodeToString(suffix))
+	qrString := fmt.Sprintf("FAKEQRIS|%s|%d", externalID, intent.Amount)
+	intent.ExternalID = &externalID
+	intent.QRString = &qrString
+	return nil
+}
+
+func (g *FakePaymentGateway) ParseCallback(body []byte, signature string) (*models.PaymentCallback, error) {
+	if !hmac.Equal([]byte(g.Sign(body)), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var payload fakeCallbackPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	return &models.PaymentCallback{
+		ExternalID: payload.ExternalID,
+		Status:     payload.Status,
+		PaidAt:     payload.PaidAt,
+	}, nil
+}
+
+// Sign - signature hex HMAC-SHA256 dari body callback
+func (g *FakePaymentGateway) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SimulateCallback - buat body dan signature callback seolah-olah dikirim gateway
+func (g *FakePaymentGateway) SimulateCallback(externalID, status string) ([]byte, string, error) {
+	body, err := json.Marshal(fakeCallbackPayload{
+		ExternalID: externalID,
+		Status:     status,
+		PaidAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, g.Sign(body), nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+	"time"
+)
+
+var validPaymentMethods = map[string]bool{
+	models.PaymentMethodCash:     true,
+	models.PaymentMethodDebit:    true,
+	models.PaymentMethodQRIS:     true,
+	models.PaymentMethodEWallet:  true,
+	models.PaymentMethodTransfer: true,
+	models.PaymentMethodPoints:   true,
+}
+
+// gatewayPaymentMethods - metode yang dikonfirmasi asinkron lewat payment gateway
+var gatewayPaymentMethods = map[string]bool{
+	models.PaymentMethodQRIS:    true,
+	models.PaymentMethodEWallet: true,
+}
+
+// SettlePayments - cocokkan tender pembayaran dengan total transaksi dan isi trx.Payments,
+// trx.PaidAmount serta trx.ChangeAmount. Pembayaran kurang ditolak, kembalian hanya boleh
+// berasal dari tender tunai. Tanpa tender, transaksi dicatat sebagai tunai pas.
+// Tender QRIS / e-wallet berstatus pending sampai dikonfirmasi payment gateway.
+func SettlePayments(trx *models.Transaction, inputs []models.PaymentInput) error {
+	if len(inputs) == 0 {
+		inputs = []models.PaymentInput{{Method: models.PaymentMethodCash, Amount: trx.TotalAmount}}
+	}
+
+	paid, cash, err := validatePaymentInputs(inputs)
+	if err != nil {
+		return err
+	}
+
+	if paid < trx.TotalAmount {
+		return &models.ValidationError{Message: fmt.Sprintf("pembayaran kurang: total %d, dibayar %d", trx.TotalAmount, paid)}
+	}
+	change := paid - trx.TotalAmount
+	if change > cash {
+		return &models.ValidationError{Message: "pembayaran non-tunai tidak boleh melebihi total transaksi"}
+	}
+
+	applyPayments(trx, inputs, change)
+	return nil
+}
+
+// SettlePayLater - pembayaran checkout kasbon: tender (opsional) menjadi uang muka dan sisa total
+// menjadi piutang, sehingga tender harus kurang dari total dan tidak ada kembalian.
+// Tender QRIS / e-wallet tidak bisa digabung dengan kasbon.
+func SettlePayLater(trx *models.Transaction, inputs []models.PaymentInput) error {
+	itemErrors := make([]models.ItemError, 0)
+	for i, input := range inputs {
+		if gatewayPaymentMethods[input.Method] {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, Field: "method", Reason: "pembayaran QRIS / e-wallet tidak bisa digabung dengan kasbon"})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return &models.ValidationError{Message: "pembayaran tidak valid", Items: itemErrors}
+	}
+
+	paid, _, err := validatePaymentInputs(inputs)
+	if err != nil {
+		return err
+	}
+	if paid >= trx.TotalAmount {
+		return &models.ValidationError{Message: fmt.Sprintf("uang muka %d sudah menutup total %d, gunakan checkout tanpa kasbon", paid, trx.TotalAmount)}
+	}
+
+	applyPayments(trx, inputs, 0)
+	return nil
+}
+
+// validatePaymentInputs - cek metode dan nominal tiap tender, kembalikan total dibayar dan total tunai
+func validatePaymentInputs(inputs []models.PaymentInput) (int, int, error) {
+	itemErrors := make([]models.ItemError, 0)
+	paid, cash, gatewayTenders := 0, 0, 0
+	for i, input := range inputs {
+		if gatewayPaymentMethods[input.Method] {
+			gatewayTenders++
+			if gatewayTenders > 1 {
+				itemErrors = append(itemErrors, models.ItemError{Index: i, Field: "method", Reason: "hanya boleh satu pembayaran QRIS / e-wallet per transaksi"})
+				continue
+			}
+		}
+		if !validPaymentMethods[input.Method] {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, Field: "method", Reason: fmt.Sprintf("metode pembayaran %q tidak dikenal", input.Method)})
+			continue
+		}
+		if input.Amount <= 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, Field: "amount", Reason: "amount harus lebih dari 0"})
+			continue
+		}
+		paid += input.Amount
+		if input.Method == models.PaymentMethodCash {
+			cash += input.Amount
+		}
+	}
+	if len(itemErrors) > 0 {
+		return 0, 0, &models.ValidationError{Message: "pembayaran tidak valid", Items: itemErrors}
+	}
+	return paid, cash, nil
+}
+
+// applyPayments - isi trx.Payments, PaidAmount dan ChangeAmount dari tender yang sudah divalidasi
+func applyPayments(trx *models.Transaction, inputs []models.PaymentInput, change int) {
+	// kembalian dipotong dari tender tunai, dimulai dari tender tunai terakhir
+	applied := make([]int, len(inputs))
+	paid := 0
+	for i, input := range inputs {
+		applied[i] = input.Amount
+		paid += input.Amount
+	}
+	remainingChange := change
+	for i := len(inputs) - 1; i >= 0 && remainingChange > 0; i-- {
+		if inputs[i].Method != models.PaymentMethodCash {
+			continue
+		}
+		cut := remainingChange
+		if cut > applied[i] {
+			cut = applied[i]
+		}
+		applied[i] -= cut
+		remainingChange -= cut
+	}
+
+	trx.Payments = make([]models.Payment, 0, len(inputs))
+	for i, input := range inputs {
+		payment := models.Payment{
+			Method:         input.Method,
+			Amount:         applied[i],
+			TenderedAmount: input.Amount,
+			Status:         models.PaymentStatusPaid,
+		}
+		if gatewayPaymentMethods[input.Method] {
+			payment.Status = models.PaymentStatusPending
+		}
+		if reference := strings.TrimSpace(input.Reference); reference != "" {
+			payment.Reference = &reference
+		}
+		trx.Payments = append(trx.Payments, payment)
+	}
+	trx.PaidAmount = paid
+	trx.ChangeAmount = change
+}
+
+// ErrPaymentGateway - payment gateway gagal membuat intent pembayaran
+var ErrPaymentGateway = errors.New("gagal membuat pembayaran di gateway")
+
+// ErrPaymentGatewayDisabled - tidak ada payment gateway yang dikonfigurasi
+var ErrPaymentGatewayDisabled = errors.New("payment gateway tidak dikonfigurasi")
+
+// PaymentService - alur pembayaran QRIS / e-wallet lewat payment gateway
+type PaymentService struct {
+	repo    *repositories.PaymentRepository
+	gateway PaymentGateway
+	ttl     time.Duration
+}
+
+// NewPaymentService - ttl adalah batas waktu pembayaran sebelum reservasi stok dilepas.
+// gateway nil berarti pembayaran QRIS / e-wallet dinonaktifkan.
+func NewPaymentService(repo *repositories.PaymentRepository, gateway PaymentGateway, ttl time.Duration) *PaymentService {
+	return &PaymentService{repo: repo, gateway: gateway, ttl: ttl}
+}
+
+func (s *PaymentService) Gateway() PaymentGateway {
+	return s.gateway
+}
+
+// PrepareIntent - bila ada tender yang pending, jadikan transaksi pending dan siapkan payment intent
+// yang akan disimpan bersama transaksi. Tender pending ditolak bila tidak ada gateway.
+func (s *PaymentService) PrepareIntent(trx *models.Transaction) error {
+	for i, p := range trx.Payments {
+		if p.Status != models.PaymentStatusPending {
+			continue
+		}
+		if s.gateway == nil {
+			return &models.ValidationError{Message: "pembayaran tidak valid", Items: []models.ItemError{
+				{Index: i, Field: "method", Reason: "pembayaran QRIS / e-wallet belum tersedia, payment gateway tidak dikonfigurasi"},
+			}}
+		}
+		trx.Status = models.TransactionStatusPending
+		trx.PaymentIntent = &models.PaymentIntent{
+			Gateway:   s.gateway.Name(),
+			Amount:    p.Amount,
+			Status:    models.PaymentStatusPending,
+			ExpiresAt: time.Now().Add(s.ttl),
+		}
+		return nil
+	}
+	return nil
+}
+
+// StartIntent - daftarkan intent ke payment gateway setelah transaksi tersimpan.
+// Bila gateway gagal, reservasi stok langsung dilepas.
+func (s *PaymentService) StartIntent(intent *models.PaymentIntent) error {
+	if err := s.gateway.CreateIntent(intent); err != nil {
+		if releaseErr := s.repo.ReleaseIntent(intent.ID, models.PaymentStatusFailed); releaseErr != nil {
+			return releaseErr
+		}
+		intent.Status = models.PaymentStatusFailed
+		return fmt.Errorf("%w: %v", ErrPaymentGateway, err)
+	}
+	return s.repo.AttachGatewayReference(intent)
+}
+
+// HandleCallback - verifikasi callback gateway lalu tandai pembayaran lunas atau gagal
+func (s *PaymentService) HandleCallback(body []byte, signature string) (*models.PaymentIntent, error) {
+	if s.gateway == nil {
+		return nil, ErrPaymentGatewayDisabled
+	}
+	callback, err := s.gateway.ParseCallback(body, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	switch callback.Status {
+	case models.PaymentStatusPaid:
+		paidAt := callback.PaidAt
+		if paidAt.IsZero() {
+			paidAt = time.Now()
+		}
+		return s.repo.CompleteIntent(callback.ExternalID, paidAt)
+	case models.PaymentStatusFailed, models.PaymentStatusExpired:
+		intent, err := s.repo.GetByExternalID(callback.ExternalID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.repo.ReleaseIntent(intent.ID, callback.Status); err != nil {
+			return nil, err
+		}
+		return s.repo.GetByExternalID(callback.ExternalID)
+	default:
+		return nil, errors.New("status callback tidak dikenal")
+	}
+}
+
+// ExpireDue - lepas reservasi stok untuk intent yang melewati batas waktu pembayaran
+func (s *PaymentService) ExpireDue() (int, error) {
+	return s.repo.ExpireDue(time.Now())
+}
+
+// GetIntent - payment intent terakhir milik transaksi, nil bila transaksi tidak memakai gateway
+func (s *PaymentService) GetIntent(transactionID int) (*models.PaymentIntent, error) {
+	return s.repo.GetByTransactionID(transactionID)
+}
@@ -17,14 +17,16 @@ func (s *ProductService) GetAll(name string) ([]models.Product, error) {
 	return s.repo.GetAll(name)
 }
 
-func (s *ProductService) Create(data *models.Product) error {
-	return s.repo.Create(data)
+// Create - userID pengguna yang login, dicatat pada movement stok awal
+func (s *ProductService) Create(data *models.Product, userID *int) error {
+	return s.repo.Create(data, userID)
 }
 
 func (s *ProductService) GetByID(id int) (*models.Product, error) {
 	return s.repo.GetByID(id)
 }
 
+// Update - stok dan harga pokok tidak ikut diubah, perubahan stok lewat penyesuaian stok
 func (s *ProductService) Update(product *models.Product) error {
 	return s.repo.Update(product)
 }
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type PromotionService struct {
+	repo *repositories.PromotionRepository
+}
+
+func NewPromotionService(repo *repositories.PromotionRepository) *PromotionService {
+	return &PromotionService{repo: repo}
+}
+
+func (s *PromotionService) GetAll() ([]models.Promotion, error) {
+	return s.repo.GetAll()
+}
+
+func (s *PromotionService) Create(data *models.Promotion) error {
+	if err := validatePromotion(data); err != nil {
+		return err
+	}
+	return s.repo.Create(data)
+}
+
+func (s *PromotionService) GetByID(id int) (*models.Promotion, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *PromotionService) Update(promotion *models.Promotion) error {
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.repo.Update(promotion)
+}
+
+func (s *PromotionService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func validatePromotion(p *models.Promotion) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("nama promo wajib diisi")
+	}
+	if p.ProductID != nil && p.CategoryID != nil {
+		return errors.New("promo hanya boleh untuk product_id atau category_id, tidak keduanya")
+	}
+
+	switch p.Type {
+	case models.PromotionTypePercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return errors.New("value promo percentage harus antara 1 dan 100")
+		}
+	case models.PromotionTypeFixed:
+		if p.Value <= 0 {
+			return errors.New("value promo fixed harus lebih dari 0")
+		}
+	case models.PromotionTypeBuyXGetY:
+		if p.ProductID == nil && p.CategoryID == nil {
+			return errors.New("promo buy_x_get_y membutuhkan product_id atau category_id")
+		}
+		if p.BuyQty <= 0 || p.GetQty <= 0 {
+			return errors.New("buy_qty dan get_qty harus lebih dari 0")
+		}
+	default:
+		return errors.New("type promo harus percentage, fixed atau buy_x_get_y")
+	}
+
+	if p.StartAt != nil && p.EndAt != nil && !p.EndAt.After(*p.StartAt) {
+		return errors.New("end_at harus setelah start_at")
+	}
+
+	return nil
+}
+
+// EvaluatePromotions - hitung diskon untuk setiap baris transaksi.
+// Promo produk/kategori tidak digabung, per baris dipilih diskon terbesar.
+// Promo seluruh belanja diterapkan setelahnya secara berurutan dan dibagi
+// proporsional ke setiap baris agar refund per baris tetap akurat.
+func EvaluatePromotions(promotions []models.Promotion, details []models.TransactionDetail) {
+	for i := range details {
+		details[i].DiscountAmount = 0
+		details[i].Discounts = nil
+	}
+
+	for i := range details {
+		line := &details[i]
+		var best *models.Promotion
+		bestAmount := 0
+		for j := range promotions {
+			p := &promotions[j]
+			if !isLinePromotion(p) || !promotionMatchesLine(p, line) {
+				continue
+			}
+			if amount := lineDiscountAmount(p, line); amount > bestAmount {
+				best = p
+				bestAmount = amount
+			}
+		}
+		if best != nil {
+			applyLineDiscount(line, best, bestAmount)
+		}
+	}
+
+	for j := range promotions {
+		p := &promotions[j]
+		if isLinePromotion(p) {
+			continue
+		}
+
+		net := 0
+		for _, line := range details {
+			net += line.Subtotal - line.DiscountAmount
+		}
+		if net <= 0 {
+			return
+		}
+
+		amount := p.Value
+		if p.Type == models.PromotionTypePercentage {
+			amount = net * p.Value / 100
+		}
+		if amount > net {
+			amount = net
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		// bagi diskon proporsional terhadap nilai bersih baris, sisa pembulatan ke baris terakhir
+		remaining := amount
+		last := -1
+		for i := range details {
+			if details[i].Subtotal-details[i].DiscountAmount > 0 {
+				last = i
+			}
+		}
+		for i := range details {
+			lineNet := details[i].Subtotal - details[i].DiscountAmount
+			if lineNet <= 0 {
+				continue
+			}
+			share := amount * lineNet / net
+			if i == last {
+				share = remaining
+			}
+			if share > lineNet {
+				share = lineNet
+			}
+			if share > 0 {
+				applyLineDiscount(&details[i], p, share)
+				remaining -= share
+			}
+		}
+	}
+}
+
+func isLinePromotion(p *models.Promotion) bool {
+	return p.ProductID != nil || p.CategoryID != nil
+}
+
+func promotionMatchesLine(p *models.Promotion, line *models.TransactionDetail) bool {
+	if p.ProductID != nil {
+		return *p.ProductID == line.ProductID
+	}
+	return line.CategoryID != nil && *p.CategoryID == *line.CategoryID
+}
+
+func lineDiscountAmount(p *models.Promotion, line *models.TransactionDetail) int {
+	amount := 0
+	switch p.Type {
+	case models.PromotionTypePercentage:
+		amount = line.Subtotal * p.Value / 100
+	case models.PromotionTypeFixed:
+		amount = p.Value * line.Quantity
+	case models.PromotionTypeBuyXGetY:
+		freeQty := line.Quantity / (p.BuyQty + p.GetQty) * p.GetQty
+		amount = freeQty * line.UnitPrice
+	}
+	if amount > line.Subtotal {
+		amount = line.Subtotal
+	}
+	return amount
+}
+
+func applyLineDiscount(line *models.TransactionDetail, p *models.Promotion, amount int) {
+	promotionID := p.ID
+	line.DiscountAmount += amount
+	line.Discounts = append(line.Discounts, models.LineDiscount{
+		PromotionID:   &promotionID,
+		PromotionName: p.Name,
+		Amount:        amount,
+	})
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+	"time"
+)
+
+type PurchaseOrderService struct {
+	repo           *repositories.PurchaseOrderRepository
+	supplierRepo   *repositories.SupplierRepository
+	receiptService *GoodsReceiptService
+}
+
+func NewPurchaseOrderService(repo *repositories.PurchaseOrderRepository, supplierRepo *repositories.SupplierRepository, receiptService *GoodsReceiptService) *PurchaseOrderService {
+	return &PurchaseOrderService{repo: repo, supplierRepo: supplierRepo, receiptService: receiptService}
+}
+
+func (s *PurchaseOrderService) GetAll(filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	if err := validatePurchaseOrderStatus(filter.Status); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAll(filter)
+}
+
+func (s *PurchaseOrderService) GetByID(id int) (*models.PurchaseOrder, error) {
+	return s.repo.GetByID(id)
+}
+
+// Create - purchase order baru selalu draft, supplier harus aktif
+func (s *PurchaseOrderService) Create(req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	expectedDate, err := s.validateRequest(&req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Create(req, expectedDate)
+}
+
+// Update - baris yang dikirim menggantikan seluruh baris, hanya selama draft
+func (s *PurchaseOrderService) Update(id int, req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	expectedDate, err := s.validateRequest(&req)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Update(id, req, expectedDate)
+}
+
+func (s *PurchaseOrderService) Send(id int) (*models.PurchaseOrder, error) {
+	return s.repo.Send(id)
+}
+
+// Receive - barang yang datang dicatat sebagai goods receipt untuk supplier purchase order,
+// penerimaan sebagian maupun penuh. unit_cost kosong memakai harga perkiraan di baris purchase order.
+func (s *PurchaseOrderService) Receive(id int, req models.ReceivePurchaseOrderRequest) (*models.GoodsReceipt, error) {
+	order, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	expectedCosts := make(map[int]int)
+	for _, line := range order.Items {
+		expectedCosts[line.ProductID] = line.UnitCost
+	}
+
+	receipt := models.GoodsReceiptRequest{
+		SupplierID:      &order.SupplierID,
+		InvoiceRef:      req.InvoiceRef,
+		Note:            req.Note,
+		Items:           make([]models.GoodsReceiptItemRequest, 0, len(req.Items)),
+		PurchaseOrderID: &order.ID,
+		UserID:          req.UserID,
+	}
+	for _, item := range req.Items {
+		unitCost := expectedCosts[item.ProductID]
+		if item.UnitCost != nil {
+			unitCost = *item.UnitCost
+		}
+		receipt.Items = append(receipt.Items, models.GoodsReceiptItemRequest{ProductID: item.ProductID, Quantity: item.Quantity, UnitCost: unitCost})
+	}
+
+	return s.receiptService.Create(receipt)
+}
+
+func (s *PurchaseOrderService) Cancel(id int, req models.CancelPurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	return s.repo.Cancel(id, strings.TrimSpace(req.Reason))
+}
+
+func (s *PurchaseOrderService) validateRequest(req *models.PurchaseOrderRequest) (*time.Time, error) {
+	supplier, err := s.supplierRepo.GetByID(req.SupplierID)
+	if errors.Is(err, repositories.ErrSupplierNotFound) {
+		return nil, &models.ValidationError{Message: "supplier tidak ditemukan"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !supplier.Active {
+		return nil, &models.ValidationError{Message: "supplier tidak aktif"}
+	}
+
+	var expectedDate *time.Time
+	if req.ExpectedDate != nil && *req.ExpectedDate != "" {
+		t, err := time.Parse("2006-01-02", *req.ExpectedDate)
+		if err != nil {
+			return nil, &models.ValidationError{Message: "expected_date harus berformat YYYY-MM-DD"}
+		}
+		expectedDate = &t
+	}
+	if req.Note != nil {
+		note := strings.TrimSpace(*req.Note)
+		req.Note = nil
+		if note != "" {
+			req.Note = &note
+		}
+	}
+
+	if len(req.Items) == 0 {
+		return nil, &models.ValidationError{Message: "items tidak boleh kosong"}
+	}
+	itemErrors := make([]models.ItemError, 0)
+	seen := make(map[int]bool)
+	for i, item := range req.Items {
+		if item.Quantity <= 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: fmt.Sprintf("quantity harus lebih dari 0, diterima %d", item.Quantity)})
+		}
+		if item.UnitCost < 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "unit_cost", Reason: "unit_cost tidak boleh negatif"})
+		}
+		if seen[item.ProductID] {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "product_id", Reason: "produk sudah ada di baris lain"})
+		}
+		seen[item.ProductID] = true
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "baris purchase order tidak valid", Items: itemErrors}
+	}
+
+	return expectedDate, nil
+}
+
+// validatePurchaseOrderStatus - filter status, "open" berarti sent dan partially_received
+func validatePurchaseOrderStatus(status string) error {
+	switch status {
+	case "", "open", models.PurchaseOrderStatusDraft, models.PurchaseOrderStatusSent, models.PurchaseOrderStatusPartiallyReceived,
+		models.PurchaseOrderStatusReceived, models.PurchaseOrderStatusCancelled:
+		return nil
+	}
+	return &models.ValidationError{Message: "status harus open, draft, sent, partially_received, received atau cancelled"}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	pdfPointsPerMM = 72 / 25.4
+	pdfMargin      = 8.0
+	// lebar karakter font Courier adalah 0.6 kali ukuran font
+	pdfCourierWidth = 0.6
+)
+
+// renderReceiptPDF - PDF satu halaman selebar kertas thermal dengan font Courier,
+// tinggi halaman mengikuti jumlah baris struk
+func renderReceiptPDF(lines []receiptLine, columns, paperWidth int) []byte {
+	pageWidth := float64(paperWidth) * pdfPointsPerMM
+	fontSize := (pageWidth - 2*pdfMargin) / (float64(columns) * pdfCourierWidth)
+	leading := fontSize * 1.25
+	pageHeight := 2*pdfMargin + float64(len(lines))*leading
+
+	var content strings.Builder
+	for i, l := range lines {
+		x := pdfMargin
+		if l.center {
+			x += float64((columns-utf8.RuneCountInString(l.text))/2) * fontSize * pdfCourierWidth
+		}
+		y := pageHeight - pdfMargin - float64(i+1)*leading + (leading - fontSize)
+		font := "F1"
+		if l.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, fontSize, x, y, escapePDFText(l.text))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents 4 0 R /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>", pageWidth, pageHeight),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+// escapePDFText - escape karakter khusus string PDF, karakter di luar ASCII diganti "?"
+func escapePDFText(text string) string {
+	text = toASCII(text)
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, "(", `\(`)
+	return strings.ReplaceAll(text, ")", `\)`)
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedPaperWidth - lebar kertas struk yang didukung hanya 58mm dan 80mm
+var ErrUnsupportedPaperWidth = errors.New("lebar kertas harus 58 atau 80 (mm)")
+
+// ErrUnsupportedReceiptFormat - format struk yang didukung: text, escpos, pdf
+var ErrUnsupportedReceiptFormat = errors.New("format struk harus text, escpos atau pdf")
+
+// receiptColumns - jumlah karakter per baris font standar printer thermal
+var receiptColumns = map[int]int{
+	58: 32,
+	80: 48,
+}
+
+var paymentMethodLabels = map[string]string{
+	models.PaymentMethodCash:     "Tunai",
+	models.PaymentMethodDebit:    "Debit",
+	models.PaymentMethodQRIS:     "QRIS",
+	models.PaymentMethodEWallet:  "E-Wallet",
+	models.PaymentMethodTransfer: "Transfer",
+	models.PaymentMethodPoints:   "Poin",
+}
+
+type ReceiptService struct {
+	transactionService *TransactionService
+	shop               models.ShopProfile
+}
+
+func NewReceiptService(transactionService *TransactionService, shop models.ShopProfile) *ReceiptService {
+	return &ReceiptService{transactionService: transactionService, shop: shop}
+}
+
+// Receipt - hasil render struk beserta content type untuk response
+type Receipt struct {
+	ContentType string
+	Body        []byte
+}
+
+// Render - render struk transaksi dalam format text, escpos atau pdf untuk kertas 58mm / 80mm
+func (s *ReceiptService) Render(transactionID int, format string, paperWidth int) (*Receipt, error) {
+	columns, ok := receiptColumns[paperWidth]
+	if !ok {
+		return nil, ErrUnsupportedPaperWidth
+	}
+	if format != models.ReceiptFormatText && format != models.ReceiptFormatESCPOS && format != models.ReceiptFormatPDF {
+		return nil, ErrUnsupportedReceiptFormat
+	}
+
+	transaction, err := s.transactionService.GetByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := buildReceiptLines(transaction, s.shop, columns)
+	switch format {
+	case models.ReceiptFormatESCPOS:
+		return &Receipt{ContentType: "application/octet-stream", Body: renderESCPOS(lines)}, nil
+	case models.ReceiptFormatPDF:
+		return &Receipt{ContentType: "application/pdf", Body: renderReceiptPDF(lines, columns, paperWidth)}, nil
+	default:
+		return &Receipt{ContentType: "text/plain; charset=utf-8", Body: renderText(lines, columns)}, nil
+	}
+}
+
+// receiptLine - satu baris struk yang sudah dipotong sesuai lebar kertas
+type receiptLine struct {
+	text   string
+	center bool
+	bold   bool
+}
+
+// buildReceiptLines - susun isi struk, dipakai bersama oleh semua format
+func buildReceiptLines(trx *models.Transaction, shop models.ShopProfile, columns int) []receiptLine {
+	lines := make([]receiptLine, 0)
+	centered := func(text string, bold bool) {
+		for _, l := range wrapText(text, columns) {
+			lines = append(lines, receiptLine{text: l, center: true, bold: bold})
+		}
+	}
+	plain := func(text string) {
+		for _, l := range wrapText(text, columns) {
+			lines = append(lines, receiptLine{text: l})
+		}
+	}
+	pair := func(label, value string, bold bool) {
+		lines = append(lines, receiptLine{text: padBetween(label, value, columns), bold: bold})
+	}
+	separator := func() {
+		lines = append(lines, receiptLine{text: strings.Repeat("-", columns)})
+	}
+
+	if shop.Name != "" {
+		centered(shop.Name, true)
+	}
+	if shop.Address != "" {
+		centered(shop.Address, false)
+	}
+	if shop.Phone != "" {
+		centered("Telp. "+shop.Phone, false)
+	}
+	separator()
+
+	plain(trx.InvoiceNumber)
+	plain(trx.CreatedAt.Format("02/01/2006 15:04"))
+	if trx.CustomerName != nil {
+		plain("Pelanggan: " + *trx.CustomerName)
+	}
+	switch trx.Status {
+	case models.TransactionStatusPending:
+		centered("MENUNGGU PEMBAYARAN", true)
+	case models.TransactionStatusExpired:
+		centered("KEDALUWARSA - TIDAK DIBAYAR", true)
+	case models.TransactionStatusVoided:
+		centered("VOID", true)
+	case models.TransactionStatusRefunded, models.TransactionStatusPartiallyRefunded:
+		centered("SALINAN - ADA PENGEMBALIAN", true)
+	}
+	separator()
+
+	for _, d := range trx.Details {
+		plain(d.ProductName)
+		pair(fmt.Sprintf("  %d x %s", d.Quantity, formatRupiah(d.UnitPrice)), formatRupiah(d.Subtotal), false)
+		for _, discount := range d.Discounts {
+			pair("  "+discount.PromotionName, "-"+formatRupiah(discount.Amount), false)
+		}
+		if len(d.Discounts) == 0 && d.DiscountAmount > 0 {
+			pair("  Diskon", "-"+formatRupiah(d.DiscountAmount), false)
+		}
+	}
+	separator()
+
+	pair("Subtotal", formatRupiah(trx.SubtotalAmount), false)
+	if trx.DiscountAmount > 0 {
+		pair("Diskon", "-"+formatRupiah(trx.DiscountAmount), false)
+	}
+	inclusiveTax, exclusiveTax := 0, 0
+	for _, d := range trx.Details {
+		if d.TaxInclusive {
+			inclusiveTax += d.TaxAmount
+		} else {
+			exclusiveTax += d.TaxAmount
+		}
+	}
+	if exclusiveTax > 0 {
+		pair("PPN", formatRupiah(exclusiveTax), false)
+	}
+	pair("TOTAL", formatRupiah(trx.TotalAmount), true)
+	if inclusiveTax > 0 {
+		pair("  Termasuk PPN", formatRupiah(inclusiveTax), false)
+	}
+	separator()
+
+	for _, p := range trx.Payments {
+		label, ok := paymentMethodLabels[p.Method]
+		if !ok {
+			label = p.Method
+		}
+		if p.Status == models.PaymentStatusPending {
+			label += " (pending)"
+		}
+		pair(label, formatRupiah(p.TenderedAmount), false)
+	}
+	if trx.ChangeAmount > 0 {
+		pair("Kembalian", formatRupiah(trx.ChangeAmount), false)
+	}
+	if trx.Receivable != nil {
+		pair("Kasbon", formatRupiah(trx.Receivable.Amount), false)
+	}
+	if trx.PointsEarned > 0 {
+		pair("Poin didapat", strconv.Itoa(trx.PointsEarned), false)
+	}
+
+	if shop.Footer != "" {
+		separator()
+		centered(shop.Footer, false)
+	}
+
+	return lines
+}
+
+func renderText(lines []receiptLine, columns int) []byte {
+	var buf bytes.Buffer
+	for _, l := range lines {
+		text := printable(l.text)
+		if l.center {
+			text = strings.Repeat(" ", (columns-utf8.RuneCountInString(text))/2) + text
+		}
+		buf.WriteString(strings.TrimRight(text, " "))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// renderESCPOS - byte stream ESC/POS: inisialisasi, perataan tengah dan tebal per baris, lalu potong kertas
+func renderESCPOS(lines []receiptLine) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0x1B, 0x40}) // ESC @ inisialisasi printer
+	for _, l := range lines {
+		align := byte(0)
+		if l.center {
+			align = 1
+		}
+		buf.Write([]byte{0x1B, 0x61, align}) // ESC a n perataan
+		if l.bold {
+			buf.Write([]byte{0x1B, 0x45, 1}) // ESC E 1 tebal
+		}
+		buf.WriteString(toASCII(strings.TrimRight(l.text, " ")))
+		if l.bold {
+			buf.Write([]byte{0x1B, 0x45, 0})
+		}
+		buf.WriteByte('\n')
+	}
+	buf.Write([]byte{0x1B, 0x64, 4})       // ESC d 4 feed 4 baris
+	buf.Write([]byte{0x1D, 0x56, 0x42, 0}) // GS V B 0 partial cut
+	return buf.Bytes()
+}
+
+// wrapText - potong teks per kata agar tidak melebihi jumlah kolom
+func wrapText(text string, columns int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0)
+	current := ""
+	for _, word := range words {
+		for utf8.RuneCountInString(word) > columns {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:columns]))
+			word = string(runes[columns:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= columns:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// padBetween - label rata kiri dan nilai rata kanan dalam satu baris, label dipotong bila terlalu panjang
+func padBetween(label, value string, columns int) string {
+	space := columns - utf8.RuneCountInString(value) - 1
+	runes := []rune(label)
+	if len(runes) > space {
+		runes = runes[:space]
+	}
+	return string(runes) + strings.Repeat(" ", columns-len(runes)-utf8.RuneCountInString(value)) + value
+}
+
+// formatRupiah - 1250000 menjadi 1.250.000
+func formatRupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.Itoa(amount)
+	var sb strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteRune(c)
+	}
+	return sign + sb.String()
+}
+
+// printable - karakter kontrol diganti "?" agar tidak memecah baris struk teks
+func printable(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+// toASCII - printer thermal memakai code page sederhana, karakter di luar ASCII diganti "?".
+// Karakter kontrol (ESC, GS, CR/LF) dari nama produk / toko juga diganti agar tidak menjadi perintah printer.
+func toASCII(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if r < 0x20 || r > 0x7E {
+			r = '?'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
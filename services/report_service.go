@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"kasir-api/models"
 	"kasir-api/repositories"
 	"time"
@@ -14,38 +15,153 @@ func NewReportService(repo *repositories.ReportRepository) *ReportService {
 	return &ReportService{repo: repo}
 }
 
-func (s *ReportService) GetDailyReport() (*models.ReportResponse, error) {
+// GetDailyReport - laporan hari ini, customerID opsional untuk membatasi ke satu pelanggan
+func (s *ReportService) GetDailyReport(customerID *int) (*models.ReportResponse, error) {
 	// Get today's date range (start of day to start of next day)
 	now := time.Now()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	endOfDay := startOfDay.Add(24 * time.Hour)
 
-	return s.GetReportByDateRange(startOfDay, endOfDay)
+	return s.GetReportByDateRange(models.ReportFilter{StartDate: startOfDay, EndDate: endOfDay, CustomerID: customerID})
 }
 
 // GetReportByDateRange - mendapatkan laporan dalam range tanggal tertentu
-func (s *ReportService) GetReportByDateRange(startDate, endDate time.Time) (*models.ReportResponse, error) {
+func (s *ReportService) GetReportByDateRange(filter models.ReportFilter) (*models.ReportResponse, error) {
 	// Get total revenue
-	totalRevenue, err := s.repo.GetTotalRevenue(startDate, endDate)
+	totalRevenue, err := s.repo.GetTotalRevenue(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get gross sales, discounts and refunds
+	summary, err := s.repo.GetSalesSummary(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get tax summary per tax class
+	taxSummary, err := s.repo.GetTaxSummary(filter)
+	if err != nil {
+		return nil, err
+	}
+	totalTax := 0
+	for _, t := range taxSummary {
+		totalTax += t.TaxAmount
+	}
+
+	// Get revenue per payment method
+	paymentMethods, err := s.repo.GetPaymentSummary(filter)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get total transactions
-	totalTransactions, err := s.repo.GetTotalTransactions(startDate, endDate)
+	totalTransactions, err := s.repo.GetTotalTransactions(filter)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get best selling product
-	bestProduct, err := s.repo.GetBestSellingProduct(startDate, endDate)
+	bestProduct, err := s.repo.GetBestSellingProduct(filter)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.ReportResponse{
+		GrossSales:     summary.GrossSales,
+		TotalDiscount:  summary.TotalDiscount,
+		TotalRefund:    summary.TotalRefund,
 		TotalRevenue:   totalRevenue,
+		TotalTax:       totalTax,
+		TaxSummary:     taxSummary,
+		PaymentMethods: paymentMethods,
 		TotalTransaksi: totalTransactions,
 		ProdukTerlaris: bestProduct,
 	}, nil
 }
+
+// ErrPendingTransactions - masih ada transaksi yang menunggu pembayaran pada tanggal tersebut
+var ErrPendingTransactions = errors.New("masih ada transaksi yang menunggu pembayaran, laporan Z belum bisa dibuat")
+
+// ErrFutureBusinessDate - laporan X / Z tidak bisa dibuat untuk tanggal yang belum terjadi
+var ErrFutureBusinessDate = errors.New("tanggal laporan belum terjadi")
+
+// GetXReport - laporan X: snapshot berjalan satu tanggal bisnis, tidak disimpan
+func (s *ReportService) GetXReport(date time.Time) (*models.EndOfDayReport, error) {
+	return s.buildEndOfDayReport(models.ReportTypeX, date)
+}
+
+// GenerateZReport - laporan Z: tutup hari. Laporan disimpan dan tanggal tersebut disegel,
+// sehingga checkout dan void / refund yang dibuat pada tanggal itu ditolak.
+func (s *ReportService) GenerateZReport(date time.Time) (*models.EndOfDayReport, error) {
+	return s.repo.SealDay(date, func() (*models.EndOfDayReport, error) {
+		pending, err := s.repo.CountPending(businessDayFilter(date))
+		if err != nil {
+			return nil, err
+		}
+		if pending > 0 {
+			return nil, ErrPendingTransactions
+		}
+		return s.buildEndOfDayReport(models.ReportTypeZ, date)
+	})
+}
+
+// GetZReport - laporan Z yang sudah disegel, date berformat YYYY-MM-DD
+func (s *ReportService) GetZReport(date string) (*models.EndOfDayReport, error) {
+	return s.repo.GetZReport(date)
+}
+
+func (s *ReportService) GetZReports() ([]models.EndOfDayReport, error) {
+	return s.repo.GetZReports()
+}
+
+// buildEndOfDayReport - laporan penjualan satu tanggal bisnis beserta jumlah item, rata-rata belanja,
+// penjualan per jam dan rentang nomor invoice
+func (s *ReportService) buildEndOfDayReport(reportType string, date time.Time) (*models.EndOfDayReport, error) {
+	now := time.Now()
+	filter := businessDayFilter(date)
+	if filter.StartDate.After(now) {
+		return nil, ErrFutureBusinessDate
+	}
+
+	summary, err := s.GetReportByDateRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	itemCount, err := s.repo.GetItemCount(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	hourly, err := s.repo.GetHourlySales(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	firstInvoice, lastInvoice, err := s.repo.GetInvoiceRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.EndOfDayReport{
+		Type:           reportType,
+		BusinessDate:   filter.StartDate.Format("2006-01-02"),
+		GeneratedAt:    now,
+		ReportResponse: *summary,
+		ItemCount:      itemCount,
+		Hourly:         hourly,
+		FirstInvoice:   firstInvoice,
+		LastInvoice:    lastInvoice,
+	}
+	if summary.TotalTransaksi > 0 {
+		report.AverageBasket = summary.TotalRevenue / summary.TotalTransaksi
+	}
+	return report, nil
+}
+
+// businessDayFilter - rentang satu hari penuh dari tanggal date, zona waktu lokal server
+func businessDayFilter(date time.Time) models.ReportFilter {
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	return models.ReportFilter{StartDate: start, EndDate: start.Add(24 * time.Hour)}
+}
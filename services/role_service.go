@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+// ErrRoleNameTaken - nama role sudah dipakai role lain
+var ErrRoleNameTaken = errors.New("nama role sudah dipakai")
+
+type RoleService struct {
+	repo     *repositories.RoleRepository
+	userRepo *repositories.UserRepository
+}
+
+func NewRoleService(repo *repositories.RoleRepository, userRepo *repositories.UserRepository) *RoleService {
+	return &RoleService{repo: repo, userRepo: userRepo}
+}
+
+func (s *RoleService) GetAll() ([]models.Role, error) {
+	return s.repo.GetAll()
+}
+
+func (s *RoleService) GetByID(id int) (*models.Role, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *RoleService) GetPermissions() ([]models.Permission, error) {
+	return s.repo.GetPermissions()
+}
+
+// Create - nama role disimpan huruf kecil, permission harus kode yang terdaftar
+func (s *RoleService) Create(req models.RoleRequest) (*models.Role, error) {
+	if err := s.validateRequest(0, &req); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(req)
+}
+
+// Update - permission yang dikirim menggantikan seluruh permission role
+func (s *RoleService) Update(id int, req models.RoleRequest) (*models.Role, error) {
+	if err := s.validateRequest(id, &req); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(id, req)
+}
+
+// SetUserRoles - ganti seluruh role pengguna, mengembalikan pengguna dengan role barunya
+func (s *RoleService) SetUserRoles(userID int, req models.UserRolesRequest) (*models.User, error) {
+	if err := s.repo.SetUserRoles(userID, normalizeRoleNames(req.Roles)); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetByID(userID)
+}
+
+func (s *RoleService) validateRequest(id int, req *models.RoleRequest) error {
+	req.Name = strings.ToLower(strings.TrimSpace(req.Name))
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Name == "" {
+		return &models.ValidationError{Message: "name wajib diisi"}
+	}
+	if strings.ContainsAny(req.Name, " \t/") {
+		return &models.ValidationError{Message: "name tidak boleh berisi spasi atau '/'"}
+	}
+	if req.Permissions == nil {
+		req.Permissions = []string{}
+	}
+
+	existing, err := s.repo.GetByName(req.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != id {
+		return ErrRoleNameTaken
+	}
+	return nil
+}
+
+// normalizeRoleNames - huruf kecil, tanpa spasi dan tanpa duplikat
+func normalizeRoleNames(names []string) []string {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type ShiftService struct {
+	repo *repositories.ShiftRepository
+}
+
+func NewShiftService(repo *repositories.ShiftRepository) *ShiftService {
+	return &ShiftService{repo: repo}
+}
+
+// Open - buka shift dengan modal awal laci
+func (s *ShiftService) Open(req models.OpenShiftRequest) (*models.Shift, error) {
+	req.CashierName = strings.TrimSpace(req.CashierName)
+	if req.CashierName == "" {
+		return nil, &models.ValidationError{Message: "cashier_name wajib diisi"}
+	}
+	if req.OpeningFloat < 0 {
+		return nil, &models.ValidationError{Message: "opening_float tidak boleh negatif"}
+	}
+	return s.repo.Open(req)
+}
+
+func (s *ShiftService) GetAll() ([]models.Shift, error) {
+	return s.repo.GetAll()
+}
+
+// GetCurrent - laporan berjalan shift yang sedang open
+func (s *ShiftService) GetCurrent() (*models.ShiftReport, error) {
+	shift, err := s.repo.GetCurrent()
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetReport(shift.ID)
+}
+
+func (s *ShiftService) GetReport(id int) (*models.ShiftReport, error) {
+	return s.repo.GetReport(id)
+}
+
+// AddCashMovement - kas masuk / keluar laci di luar penjualan, alasan wajib diisi
+func (s *ShiftService) AddCashMovement(shiftID int, req models.CashMovementRequest) (*models.CashMovement, error) {
+	if req.Type != models.CashMovementIn && req.Type != models.CashMovementOut {
+		return nil, &models.ValidationError{Message: "type harus cash_in atau cash_out"}
+	}
+	if req.Amount <= 0 {
+		return nil, &models.ValidationError{Message: "amount harus lebih dari 0"}
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, &models.ValidationError{Message: "reason wajib diisi"}
+	}
+	return s.repo.AddCashMovement(shiftID, req)
+}
+
+// Close - tutup shift dengan uang fisik hasil hitung kasir, menghasilkan laporan shift beserta selisihnya
+func (s *ShiftService) Close(id int, req models.CloseShiftRequest) (*models.ShiftReport, error) {
+	if req.CountedCash == nil {
+		return nil, &models.ValidationError{Message: "counted_cash wajib diisi"}
+	}
+	if *req.CountedCash < 0 {
+		return nil, &models.ValidationError{Message: "counted_cash tidak boleh negatif"}
+	}
+	return s.repo.Close(id, req)
+}
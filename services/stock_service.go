@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+type StockService struct {
+	repo *repositories.StockRepository
+}
+
+func NewStockService(repo *repositories.StockRepository) *StockService {
+	return &StockService{repo: repo}
+}
+
+// GetHistory - kartu stok produk beserta saldo saat ini
+func (s *StockService) GetHistory(productID int, filter models.StockMovementFilter) (*models.StockMovementHistory, error) {
+	switch filter.Type {
+	case "", models.StockMovementSale, models.StockMovementRefund, models.StockMovementAdjustment,
+		models.StockMovementReceipt, models.StockMovementTransfer, models.StockMovementOpname:
+	default:
+		return nil, &models.ValidationError{Message: "type harus sale, refund, adjustment, receipt, transfer atau opname"}
+	}
+	return s.repo.GetHistory(productID, filter)
+}
+
+// CreateAdjustment - penyesuaian stok beberapa produk sekaligus. Rusak, kedaluwarsa dan hilang harus
+// mengurangi stok, ditemukan harus menambah, koreksi boleh keduanya.
+func (s *StockService) CreateAdjustment(req models.StockAdjustmentRequest) (*models.StockAdjustment, error) {
+	req.Note = strings.TrimSpace(req.Note)
+	if len(req.Items) == 0 {
+		return nil, &models.ValidationError{Message: "items tidak boleh kosong"}
+	}
+
+	itemErrors := make([]models.ItemError, 0)
+	for i, item := range req.Items {
+		switch {
+		case item.ProductID <= 0:
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "product_id", Reason: "product_id wajib diisi"})
+		case item.Quantity == 0:
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: "quantity tidak boleh 0"})
+		}
+
+		switch item.Reason {
+		case models.AdjustmentReasonDamaged, models.AdjustmentReasonExpired, models.AdjustmentReasonLost:
+			if item.Quantity > 0 {
+				itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: fmt.Sprintf("reason %s harus mengurangi stok (quantity negatif)", item.Reason)})
+			}
+		case models.AdjustmentReasonFound:
+			if item.Quantity < 0 {
+				itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "quantity", Reason: "reason found harus menambah stok (quantity positif)"})
+			}
+		case models.AdjustmentReasonCorrection:
+		default:
+			itemErrors = append(itemErrors, models.ItemError{Index: i, ProductID: item.ProductID, Field: "reason", Reason: "reason harus damaged, expired, lost, found atau correction"})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "penyesuaian stok tidak valid", Items: itemErrors}
+	}
+
+	return s.repo.CreateAdjustment(req)
+}
+
+// GetAdjustments - daftar penyesuaian stok, default halaman 1 dengan 20 data per halaman
+func (s *StockService) GetAdjustments(filter models.StockAdjustmentFilter) (*models.StockAdjustmentListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	adjustments, total, err := s.repo.GetAdjustments(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StockAdjustmentListResponse{
+		Data:  adjustments,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}, nil
+}
+
+func (s *StockService) GetAdjustment(id int) (*models.StockAdjustment, error) {
+	return s.repo.GetAdjustment(id)
+}
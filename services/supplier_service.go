@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+// ErrSupplierNameTaken - nama supplier sudah dipakai supplier lain
+var ErrSupplierNameTaken = errors.New("nama supplier sudah dipakai")
+
+type SupplierService struct {
+	repo   *repositories.SupplierRepository
+	poRepo *repositories.PurchaseOrderRepository
+}
+
+func NewSupplierService(repo *repositories.SupplierRepository, poRepo *repositories.PurchaseOrderRepository) *SupplierService {
+	return &SupplierService{repo: repo, poRepo: poRepo}
+}
+
+func (s *SupplierService) GetAll(search string) ([]models.Supplier, error) {
+	return s.repo.GetAll(strings.TrimSpace(search))
+}
+
+func (s *SupplierService) GetByID(id int) (*models.Supplier, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *SupplierService) Create(req models.SupplierRequest) (*models.Supplier, error) {
+	if err := s.validateRequest(0, &req); err != nil {
+		return nil, err
+	}
+	return s.repo.Create(req)
+}
+
+func (s *SupplierService) Update(id int, req models.SupplierRequest) (*models.Supplier, error) {
+	if err := s.validateRequest(id, &req); err != nil {
+		return nil, err
+	}
+	return s.repo.Update(id, req)
+}
+
+// GetPurchaseOrders - purchase order milik supplier, status kosong berarti yang masih open
+func (s *SupplierService) GetPurchaseOrders(supplierID int, status string) ([]models.PurchaseOrder, error) {
+	if _, err := s.repo.GetByID(supplierID); err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = "open"
+	}
+	if err := validatePurchaseOrderStatus(status); err != nil {
+		return nil, err
+	}
+	return s.poRepo.GetAll(models.PurchaseOrderFilter{Status: status, SupplierID: &supplierID})
+}
+
+func (s *SupplierService) validateRequest(id int, req *models.SupplierRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return &models.ValidationError{Message: "name wajib diisi"}
+	}
+	if req.PaymentTermDays < 0 {
+		return &models.ValidationError{Message: "payment_term_days tidak boleh negatif"}
+	}
+
+	existing, err := s.repo.GetByName(req.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != id {
+		return ErrSupplierNameTaken
+	}
+	return nil
+}
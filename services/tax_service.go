@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"math"
+	"strings"
+)
+
+type TaxClassService struct {
+	repo *repositories.TaxClassRepository
+}
+
+func NewTaxClassService(repo *repositories.TaxClassRepository) *TaxClassService {
+	return &TaxClassService{repo: repo}
+}
+
+func (s *TaxClassService) GetAll() ([]models.TaxClass, error) {
+	return s.repo.GetAll()
+}
+
+func (s *TaxClassService) Create(data *models.TaxClass) error {
+	if err := validateTaxClass(data); err != nil {
+		return err
+	}
+	return s.repo.Create(data)
+}
+
+func (s *TaxClassService) GetByID(id int) (*models.TaxClass, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TaxClassService) Update(taxClass *models.TaxClass) error {
+	if err := validateTaxClass(taxClass); err != nil {
+		return err
+	}
+	return s.repo.Update(taxClass)
+}
+
+func (s *TaxClassService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
+
+func validateTaxClass(t *models.TaxClass) error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("nama kelas pajak wajib diisi")
+	}
+	if t.Rate < 0 || t.Rate > 100 {
+		return errors.New("rate pajak harus antara 0 dan 100")
+	}
+	return nil
+}
+
+// ApplyTax - hitung pajak per baris dari nilai setelah diskon.
+// Harga inklusif: pajak = dasar * rate / (100 + rate), total baris tidak berubah.
+// Harga eksklusif: pajak = dasar * rate / 100, ditambahkan ke total baris.
+func ApplyTax(details []models.TransactionDetail) {
+	for i := range details {
+		line := &details[i]
+		line.TaxAmount = 0
+		if line.TaxClassID == nil || line.TaxRate <= 0 {
+			continue
+		}
+
+		base := float64(line.Subtotal - line.DiscountAmount)
+		if line.TaxInclusive {
+			line.TaxAmount = int(math.Round(base * line.TaxRate / (100 + line.TaxRate)))
+		} else {
+			line.TaxAmount = int(math.Round(base * line.TaxRate / 100))
+		}
+	}
+}
@@ -1,16 +1,257 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"strings"
+	"time"
 )
+
+// maxCheckoutRetries - batas percobaan ulang checkout mode optimistic saat terjadi konflik stok
+const maxCheckoutRetries = 3
+
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo           *repositories.TransactionRepository
+	refundRepo     *repositories.RefundRepository
+	promotionRepo  *repositories.PromotionRepository
+	customerRepo   *repositories.CustomerRepository
+	debtRepo       *repositories.DebtRepository
+	paymentService *PaymentService
+	loyalty        *LoyaltyService
+	requireShift   bool
+}
+
+func NewTransactionService(repo *repositories.TransactionRepository, refundRepo *repositories.RefundRepository, promotionRepo *repositories.PromotionRepository, customerRepo *repositories.CustomerRepository, debtRepo *repositories.DebtRepository, paymentService *PaymentService, loyalty *LoyaltyService, requireShift bool) *TransactionService {
+	return &TransactionService{repo: repo, refundRepo: refundRepo, promotionRepo: promotionRepo, customerRepo: customerRepo, debtRepo: debtRepo, paymentService: paymentService, loyalty: loyalty, requireShift: requireShift}
+}
+
+// Checkout - useLock = true mengunci baris produk selama checkout,
+// useLock = false memakai mode optimistic dan mengulang checkout bila stok berubah di tengah jalan.
+// Checkout dengan tender QRIS / e-wallet menghasilkan transaksi pending beserta payment intent.
+// Bila requireShift aktif, checkout ditolak saat tidak ada shift open.
+// Bila payment gateway gagal setelah transaksi tersimpan, transaksi dikembalikan bersama error-nya.
+func (s *TransactionService) Checkout(req models.CheckoutRequest, useLock bool) (*models.Transaction, error) {
+	items, err := ValidateCheckoutItems(req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	var debtor *models.Debtor
+	if req.DebtorID != nil {
+		debtor, err = s.debtRepo.GetByID(*req.DebtorID)
+		if errors.Is(err, repositories.ErrDebtorNotFound) {
+			return nil, &models.ValidationError{Message: fmt.Sprintf("debitur %d tidak ditemukan", *req.DebtorID)}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	customerID, member, err := s.resolveCustomer(req, debtor)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := s.promotionRepo.GetActive(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	adjust := func(trx *models.Transaction) error {
+		if s.requireShift && trx.ShiftID == nil {
+			return repositories.ErrNoOpenShift
+		}
+		trx.CustomerID = customerID
+		trx.UserID = req.UserID
+		EvaluatePromotions(promotions, trx.Details)
+		ApplyTax(trx.Details)
+		trx.CalculateTotals()
+		if debtor != nil {
+			if err := SettlePayLater(trx, req.Payments); err != nil {
+				return err
+			}
+			// limit kredit dicek repository di dalam transaksi checkout
+			trx.Receivable = &models.Receivable{DebtorID: debtor.ID, Amount: trx.TotalAmount - trx.PaidAmount}
+		} else if err := SettlePayments(trx, req.Payments); err != nil {
+			return err
+		}
+		if err := s.loyalty.ApplyPoints(trx, member); err != nil {
+			return err
+		}
+		return s.paymentService.PrepareIntent(trx)
+	}
+
+	completion := repositories.CheckoutCompletion{IdempotencyKey: req.IdempotencyKey, CartID: req.CartID}
+	transaction, err := s.createTransaction(items, useLock, adjust, completion)
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.PaymentIntent != nil {
+		if err := s.paymentService.StartIntent(transaction.PaymentIntent); err != nil {
+			// intent yang gagal sudah melepas reservasi stok dan mengubah transaksi menjadi expired
+			if transaction.PaymentIntent.Status == models.PaymentStatusFailed {
+				transaction.Status = models.TransactionStatusExpired
+			}
+			return transaction, err
+		}
+	}
+
+	return transaction, nil
 }
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+
+// resolveCustomer - pelanggan dari customer_id, member_phone atau debitur kasbon. Pelanggan yang
+// memiliki nomor telepon adalah member dan mendapat poin.
+func (s *TransactionService) resolveCustomer(req models.CheckoutRequest, debtor *models.Debtor) (*int, bool, error) {
+	var customer *models.Customer
+	var err error
+
+	if req.CustomerID != nil {
+		customer, err = s.customerRepo.GetByID(*req.CustomerID)
+		if errors.Is(err, repositories.ErrCustomerNotFound) {
+			return nil, false, &models.ValidationError{Message: fmt.Sprintf("pelanggan %d tidak ditemukan", *req.CustomerID)}
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if strings.TrimSpace(req.MemberPhone) != "" {
+		member, err := s.customerRepo.GetByPhone(NormalizePhone(req.MemberPhone))
+		if errors.Is(err, repositories.ErrCustomerNotFound) {
+			return nil, false, &models.ValidationError{Message: fmt.Sprintf("member dengan nomor %s tidak ditemukan", req.MemberPhone)}
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if customer != nil && customer.ID != member.ID {
+			return nil, false, &models.ValidationError{Message: "customer_id dan member_phone merujuk pelanggan yang berbeda"}
+		}
+		customer = member
+	}
+
+	if debtor != nil {
+		if customer != nil && customer.ID != debtor.CustomerID {
+			return nil, false, &models.ValidationError{Message: "debitur kasbon bukan pelanggan pada transaksi ini"}
+		}
+		if customer == nil {
+			customer, err = s.customerRepo.GetByID(debtor.CustomerID)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	if customer == nil {
+		return nil, false, nil
+	}
+	return &customer.ID, customer.Phone != nil, nil
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem, useLock bool) (*models.Transaction, error) {
-		return s.repo.CreateTransaction(items)
-}
\ No newline at end of file
+// createTransaction - simpan transaksi, mode optimistic diulang sampai maxCheckoutRetries kali
+func (s *TransactionService) createTransaction(items []models.CheckoutItem, useLock bool, adjust repositories.PriceAdjuster, completion repositories.CheckoutCompletion) (*models.Transaction, error) {
+	if useLock {
+		return s.repo.CreateTransaction(items, true, adjust, completion)
+	}
+
+	var err error
+	for attempt := 0; attempt < maxCheckoutRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt*20) * time.Millisecond)
+		}
+
+		var transaction *models.Transaction
+		transaction, err = s.repo.CreateTransaction(items, false, adjust, completion)
+		if !errors.Is(err, repositories.ErrStockConflict) {
+			return transaction, err
+		}
+	}
+	return nil, err
+}
+
+// GetAll - daftar riwayat transaksi, default halaman 1 dengan 20 data per halaman
+func (s *TransactionService) GetAll(filter models.TransactionFilter) (*models.TransactionListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	transactions, total, err := s.repo.GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TransactionListResponse{
+		Data:  transactions,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}, nil
+}
+
+func (s *TransactionService) GetByID(id int) (*models.Transaction, error) {
+	transaction, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withPaymentIntent(transaction)
+}
+
+// GetByInvoiceNumber - cari transaksi dengan nomor invoice, misalnya INV/OUTLET/2026/10/000123
+func (s *TransactionService) GetByInvoiceNumber(invoiceNumber string) (*models.Transaction, error) {
+	transaction, err := s.repo.GetByInvoiceNumber(invoiceNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.withPaymentIntent(transaction)
+}
+
+func (s *TransactionService) withPaymentIntent(transaction *models.Transaction) (*models.Transaction, error) {
+	var err error
+	transaction.PaymentIntent, err = s.paymentService.GetIntent(transaction.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// Void - membatalkan seluruh sisa transaksi dan mengembalikan stoknya, req.Items diabaikan
+func (s *TransactionService) Void(transactionID int, req models.RefundRequest) (*models.Refund, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, &models.ValidationError{Message: "reason wajib diisi"}
+	}
+	return s.refundRepo.CreateRefund(transactionID, models.RefundTypeVoid, models.RefundRequest{Reason: req.Reason, UserID: req.UserID})
+}
+
+// Refund - mengembalikan sebagian item transaksi per baris dan quantity
+func (s *TransactionService) Refund(transactionID int, req models.RefundRequest) (*models.Refund, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, &models.ValidationError{Message: "reason wajib diisi"}
+	}
+	if len(req.Items) == 0 {
+		return nil, &models.ValidationError{Message: "items tidak boleh kosong"}
+	}
+
+	itemErrors := make([]models.ItemError, 0)
+	for i, item := range req.Items {
+		if item.Quantity <= 0 {
+			itemErrors = append(itemErrors, models.ItemError{Index: i, Field: "quantity", Reason: fmt.Sprintf("quantity harus lebih dari 0, diterima %d", item.Quantity)})
+		}
+	}
+	if len(itemErrors) > 0 {
+		return nil, &models.ValidationError{Message: "item refund tidak valid", Items: itemErrors}
+	}
+
+	return s.refundRepo.CreateRefund(transactionID, models.RefundTypeRefund, req)
+}
+
+func (s *TransactionService) GetRefunds(transactionID int) ([]models.Refund, error) {
+	return s.refundRepo.GetByTransactionID(transactionID)
+}